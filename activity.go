@@ -0,0 +1,114 @@
+package libyear
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/nieomylnieja/go-libyear/internal"
+)
+
+const (
+	// DefaultActivityReleases is the default number of most recent releases
+	// used to calculate the median release interval.
+	DefaultActivityReleases = 10
+	// DefaultInactivityPeriod is the default period without any release
+	// after which the module is considered inactive.
+	DefaultInactivityPeriod = 2 * 365 * 24 * time.Hour
+)
+
+// calculateActivity computes the release cadence of the module based on
+// the release dates of its most recent versions.
+// If the module has no versions, e.g. it was never tagged, latest version is its only release.
+func (c Command) calculateActivity(repo ModulesRepo, latest *internal.Module) (*internal.Activity, error) {
+	releases, err := c.getAllReleases(repo, latest)
+	if err != nil {
+		return nil, err
+	}
+	releasesCount := c.activityReleases
+	if releasesCount <= 0 {
+		releasesCount = DefaultActivityReleases
+	}
+	// We need one more release than the number of intervals.
+	if len(releases) > releasesCount+1 {
+		releases = releases[len(releases)-releasesCount-1:]
+	}
+
+	// Latest version is always a release, its time is already known.
+	times := make([]time.Time, 0, len(releases)+1)
+	times = append(times, latest.Time)
+	for _, release := range releases {
+		if release.Path == latest.Path && release.Version.Equal(latest.Version) {
+			continue
+		}
+		info, err := repo.GetInfo(release.Path, release.Version)
+		if err != nil {
+			// Listed versions are not always available, e.g. when the tag was removed.
+			c.log().Warn("skipping version in activity calculation",
+				"path", release.Path, "version", "v"+release.Version.String(), "error", err)
+			continue
+		}
+		if !c.ageLimit.IsZero() && info.Time.After(c.ageLimit) {
+			continue
+		}
+		times = append(times, info.Time)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	now := time.Now()
+	if !c.ageLimit.IsZero() {
+		now = c.ageLimit
+	}
+	lastRelease := times[len(times)-1]
+	inactivityPeriod := c.inactivityPeriod
+	if inactivityPeriod <= 0 {
+		inactivityPeriod = DefaultInactivityPeriod
+	}
+	return &internal.Activity{
+		LastRelease:           lastRelease,
+		SinceLastRelease:      now.Sub(lastRelease),
+		MedianReleaseInterval: medianInterval(times),
+		Inactive:              now.Sub(lastRelease) > inactivityPeriod,
+	}, nil
+}
+
+// getAllReleases lists stable versions, not greater than latest, from all the paths of the latest module.
+// Releases are sorted in ascending order.
+func (c Command) getAllReleases(repo ModulesRepo, latest *internal.Module) ([]*internal.Module, error) {
+	paths := latest.AllPaths
+	if len(paths) == 0 {
+		paths = []string{latest.Path}
+	}
+	var releases []*internal.Module
+	for _, path := range paths {
+		versions, err := c.getVersionsForPath(repo, path, false)
+		if err != nil && err != errNoVersions {
+			return nil, err
+		}
+		for _, version := range c.filterExcluded(path, versions) {
+			if version.Prerelease() != "" || version.GreaterThan(latest.Version) {
+				continue
+			}
+			releases = append(releases, &internal.Module{Path: path, Version: version})
+		}
+	}
+	sort.Slice(releases, func(i, j int) bool { return releases[i].Version.LessThan(releases[j].Version) })
+	return releases, nil
+}
+
+// medianInterval calculates the median of intervals between sorted times.
+func medianInterval(times []time.Time) time.Duration {
+	if len(times) < 2 {
+		return 0
+	}
+	intervals := make([]time.Duration, 0, len(times)-1)
+	for i := 1; i < len(times); i++ {
+		intervals = append(intervals, times[i].Sub(times[i-1]))
+	}
+	slices.Sort(intervals)
+	mid := len(intervals) / 2
+	if len(intervals)%2 == 0 {
+		return (intervals[mid-1] + intervals[mid]) / 2
+	}
+	return intervals[mid]
+}
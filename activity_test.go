@@ -0,0 +1,119 @@
+package libyear
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/nieomylnieja/go-libyear/internal"
+	"github.com/nieomylnieja/go-libyear/internal/mocks"
+)
+
+func TestCommand_CalculateActivity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	path := "github.com/nieomylnieja/go-libyear"
+	releases := map[string]string{
+		"v1.0.0": "2023-01-01",
+		"v1.1.0": "2023-01-11",
+		"v1.2.0": "2023-01-16",
+		"v1.3.0": "2023-02-05",
+	}
+	modulesRepo := mocks.NewMockModulesRepo(ctrl)
+	modulesRepo.EXPECT().
+		GetVersions(path).
+		Times(1).
+		Return([]*semver.Version{
+			semver.MustParse("v1.0.0"),
+			semver.MustParse("v1.1.0"),
+			semver.MustParse("v1.2.0"),
+			semver.MustParse("v1.3.0"),
+			semver.MustParse("v1.4.0-rc1"),
+		}, nil)
+	for version, date := range releases {
+		// v1.0.0 is outside of the analyzed window and v1.3.0 is the latest version.
+		if version == "v1.0.0" || version == "v1.3.0" {
+			continue
+		}
+		modulesRepo.EXPECT().
+			GetInfo(path, semver.MustParse(version)).
+			Times(1).
+			Return(&internal.Module{Path: path, Version: semver.MustParse(version), Time: mustParseTime(t, date)}, nil)
+	}
+	cmd := Command{
+		ageLimit:         mustParseTime(t, "2024-01-01"),
+		activityReleases: 2,
+		inactivityPeriod: 300 * 24 * time.Hour,
+	}
+
+	activity, err := cmd.calculateActivity(modulesRepo, &internal.Module{
+		Path:    path,
+		Version: semver.MustParse("v1.3.0"),
+		Time:    mustParseTime(t, "2023-02-05"),
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, &internal.Activity{
+		LastRelease:           mustParseTime(t, "2023-02-05"),
+		SinceLastRelease:      330 * 24 * time.Hour,
+		MedianReleaseInterval: 12*24*time.Hour + 12*time.Hour,
+		Inactive:              true,
+	}, activity)
+}
+
+func TestCommand_CalculateActivity_MissingInfo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	path := "github.com/nieomylnieja/go-libyear"
+	modulesRepo := mocks.NewMockModulesRepo(ctrl)
+	modulesRepo.EXPECT().
+		GetVersions(path).
+		Times(1).
+		Return([]*semver.Version{semver.MustParse("v1.0.0"), semver.MustParse("v1.1.0")}, nil)
+	modulesRepo.EXPECT().
+		GetInfo(path, semver.MustParse("v1.0.0")).
+		Times(1).
+		Return(nil, errors.New("not found"))
+	cmd := Command{ageLimit: mustParseTime(t, "2024-01-01")}
+
+	activity, err := cmd.calculateActivity(modulesRepo, &internal.Module{
+		Path:    path,
+		Version: semver.MustParse("v1.1.0"),
+		Time:    mustParseTime(t, "2023-01-01"),
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, &internal.Activity{
+		LastRelease:      mustParseTime(t, "2023-01-01"),
+		SinceLastRelease: 365 * 24 * time.Hour,
+	}, activity)
+}
+
+func TestMedianInterval(t *testing.T) {
+	for name, test := range map[string]struct {
+		Dates    []string
+		Expected time.Duration
+	}{
+		"no releases":    {},
+		"single release": {Dates: []string{"2023-01-01"}},
+		"odd intervals": {
+			Dates:    []string{"2023-01-01", "2023-01-02", "2023-01-12", "2023-01-15"},
+			Expected: 72 * time.Hour,
+		},
+		"even intervals": {
+			Dates:    []string{"2023-01-01", "2023-01-02", "2023-01-05"},
+			Expected: 48 * time.Hour,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			times := make([]time.Time, 0, len(test.Dates))
+			for _, date := range test.Dates {
+				times = append(times, mustParseTime(t, date))
+			}
+			assert.Equal(t, test.Expected, medianInterval(times))
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package libyear
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/nieomylnieja/go-libyear/internal"
+)
+
+type AggregationStrategy string
+
+const (
+	AggregationSum        AggregationStrategy = "sum"
+	AggregationMean       AggregationStrategy = "mean"
+	AggregationMedian     AggregationStrategy = "median"
+	AggregationMax        AggregationStrategy = "max"
+	AggregationPercentile AggregationStrategy = "percentile"
+)
+
+// Aggregation defines how the dependencies' metrics are aggregated into the main module's metrics.
+// Zero value is equivalent to [AggregationSum].
+type Aggregation struct {
+	Strategy AggregationStrategy
+	// Percentile is only used with [AggregationPercentile], it must be in (0, 100] range.
+	Percentile float64
+}
+
+// ParseAggregation parses the aggregation strategy name.
+// Percentiles are defined with 'p' prefix, e.g. 'p90'.
+func ParseAggregation(s string) (Aggregation, error) {
+	switch strategy := AggregationStrategy(s); strategy {
+	case AggregationSum, AggregationMean, AggregationMedian, AggregationMax:
+		return Aggregation{Strategy: strategy}, nil
+	}
+	if p, ok := strings.CutPrefix(s, "p"); ok {
+		percentile, err := strconv.ParseFloat(p, 64)
+		if err == nil && percentile > 0 && percentile <= 100 {
+			return Aggregation{Strategy: AggregationPercentile, Percentile: percentile}, nil
+		}
+	}
+	return Aggregation{}, errors.Errorf(
+		"invalid aggregation '%s', expected one of: sum, mean, median, max or a percentile, e.g. p90", s)
+}
+
+func (a Aggregation) String() string {
+	switch a.Strategy {
+	case "":
+		return string(AggregationSum)
+	case AggregationPercentile:
+		return "p" + strconv.FormatFloat(a.Percentile, 'f', -1, 64)
+	default:
+		return string(a.Strategy)
+	}
+}
+
+// aggregate calculates the weighted aggregate of the values.
+func (a Aggregation) aggregate(values, weights []float64) float64 {
+	switch a.Strategy {
+	case AggregationMean:
+		var sum, totalWeight float64
+		for i := range values {
+			sum += weights[i] * values[i]
+			totalWeight += weights[i]
+		}
+		if totalWeight == 0 {
+			return 0
+		}
+		return sum / totalWeight
+	case AggregationMedian:
+		return weightedPercentile(values, weights, 50)
+	case AggregationPercentile:
+		return weightedPercentile(values, weights, a.Percentile)
+	case AggregationMax:
+		var maxValue float64
+		for i := range values {
+			if weights[i] > 0 && values[i] > maxValue {
+				maxValue = values[i]
+			}
+		}
+		return maxValue
+	default:
+		var sum float64
+		for i := range values {
+			sum += weights[i] * values[i]
+		}
+		return sum
+	}
+}
+
+// weightedPercentile finds the smallest value for which the cumulative weight
+// of values lower or equal to it reaches the percentile of the total weight.
+// If the cumulative weight is exactly equal to the percentile, the result is
+// interpolated with the next value, e.g. the median of [1, 2, 3, 4] is 2.5.
+func weightedPercentile(values, weights []float64, percentile float64) float64 {
+	indexes := make([]int, 0, len(values))
+	var totalWeight float64
+	for i := range values {
+		if weights[i] > 0 {
+			indexes = append(indexes, i)
+			totalWeight += weights[i]
+		}
+	}
+	if len(indexes) == 0 {
+		return 0
+	}
+	slices.SortStableFunc(indexes, func(i, j int) int {
+		switch {
+		case values[i] < values[j]:
+			return -1
+		case values[i] > values[j]:
+			return 1
+		default:
+			return 0
+		}
+	})
+	target := percentile / 100 * totalWeight
+	var cumulative float64
+	for n, i := range indexes {
+		cumulative += weights[i]
+		if cumulative < target && n < len(indexes)-1 {
+			continue
+		}
+		if cumulative == target && n < len(indexes)-1 {
+			return (values[i] + values[indexes[n+1]]) / 2
+		}
+		return values[i]
+	}
+	return values[indexes[len(indexes)-1]]
+}
+
+type Weighting string
+
+const (
+	// WeightingNone gives every dependency the same weight.
+	WeightingNone Weighting = "none"
+	// WeightingDirect gives direct dependencies full weight
+	// and indirect dependencies [IndirectDependencyWeight].
+	WeightingDirect Weighting = "direct"
+	// WeightingPackages weights dependencies by the number of their packages imported by the project.
+	// Dependencies which are only used by tests have zero weight.
+	WeightingPackages Weighting = "packages"
+)
+
+// IndirectDependencyWeight is the weight of indirect dependencies used with [WeightingDirect].
+const IndirectDependencyWeight = 0.5
+
+// ParseWeighting parses the weighting strategy name.
+func ParseWeighting(s string) (Weighting, error) {
+	switch weighting := Weighting(s); weighting {
+	case WeightingNone, WeightingDirect, WeightingPackages:
+		return weighting, nil
+	default:
+		return "", errors.Errorf("invalid weighting '%s', expected one of: none, direct, packages", s)
+	}
+}
+
+// ReadWeightsFile reads user-defined modules' weights from a JSON file.
+// The file contains an object which maps module paths to their weights,
+// e.g. {"github.com/pkg/errors": 0.5}.
+func ReadWeightsFile(path string) (map[string]float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var weights map[string]float64
+	if err = json.Unmarshal(data, &weights); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode weights file %s", path)
+	}
+	for modulePath, weight := range weights {
+		if weight < 0 {
+			return nil, errors.Errorf("weight of %s must not be negative, got: %v", modulePath, weight)
+		}
+	}
+	return weights, nil
+}
+
+type PackageUsageLister interface {
+	ListPackageUsage() (map[string]*internal.PackageUsage, error)
+}
+
+// loadPackageUsage sets the usage of each module's packages by the main module.
+func (c Command) loadPackageUsage(modules []*internal.Module) error {
+	if c.packages == nil {
+		return errors.New("project directory must be provided in order to analyze packages usage")
+	}
+	usage, err := c.packages.ListPackageUsage()
+	if err != nil {
+		return err
+	}
+	for _, module := range modules {
+		path := module.Path
+		// Packages of the replacement are reported under the replaced module path.
+		if module.Replace != nil && module.Replace.Kind == internal.ReplaceFork {
+			path = module.Replace.Path
+		}
+		module.Usage = usage[path]
+		if module.Usage == nil {
+			module.Usage = &internal.PackageUsage{}
+		}
+	}
+	return nil
+}
+
+// isDefaultAggregation reports whether the dependencies' metrics are simply summed up.
+func (c Command) isDefaultAggregation() bool {
+	return c.aggregation.String() == string(AggregationSum) &&
+		(c.weighting == "" || c.weighting == WeightingNone) &&
+		len(c.weights) == 0
+}
+
+// describeAggregation returns a short description of the selected aggregation, e.g. 'mean, weighted by direct'.
+// The default aggregation is not described.
+func (c Command) describeAggregation() string {
+	if c.isDefaultAggregation() {
+		return ""
+	}
+	description := c.aggregation.String()
+	switch {
+	case c.weighting != "" && c.weighting != WeightingNone && len(c.weights) > 0:
+		description += ", weighted by " + string(c.weighting) + " and config"
+	case c.weighting != "" && c.weighting != WeightingNone:
+		description += ", weighted by " + string(c.weighting)
+	case len(c.weights) > 0:
+		description += ", weighted by config"
+	}
+	return description
+}
+
+// moduleWeight returns the weight of the module based on the selected weighting.
+// User-defined weights take precedence.
+func (c Command) moduleWeight(module *internal.Module) float64 {
+	if weight, ok := c.weights[module.Path]; ok {
+		return weight
+	}
+	switch c.weighting {
+	case WeightingDirect:
+		if module.Indirect {
+			return IndirectDependencyWeight
+		}
+		return 1
+	case WeightingPackages:
+		// Test-only dependencies are not part of the production code.
+		if !module.Usage.IsProduction() {
+			return 0
+		}
+		return float64(module.Usage.Packages)
+	default:
+		return 1
+	}
+}
+
+// aggregate calculates the main module's metrics from its dependencies.
+func (c Command) aggregate(mainModule *internal.Module, modules []*internal.Module) {
+	var (
+		weights   = make([]float64, 0, len(modules))
+		libyears  = make([]float64, 0, len(modules))
+		releases  = make([]float64, 0, len(modules))
+		versions  [3][]float64
+		aggregate = c.aggregation.aggregate
+	)
+	for _, module := range modules {
+		// Upstream modules are shadowed by their replacements, they're not used by the main module.
+		if module.IsUpstream() {
+			continue
+		}
+		weights = append(weights, c.moduleWeight(module))
+		libyears = append(libyears, module.Libyear)
+		releases = append(releases, float64(module.ReleasesDiff))
+		for i := range versions {
+			versions[i] = append(versions[i], float64(module.VersionsDiff[i]))
+		}
+		mainModule.Vulnerabilities = append(mainModule.Vulnerabilities, module.Vulnerabilities...)
+		mainModule.FixedLibyear += module.FixedLibyear
+	}
+	mainModule.Libyear = aggregate(libyears, weights)
+	mainModule.ReleasesDiff = int(math.Round(aggregate(releases, weights)))
+	for i := range versions {
+		mainModule.VersionsDiff[i] = int64(math.Round(aggregate(versions[i], weights)))
+	}
+}
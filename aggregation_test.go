@@ -0,0 +1,177 @@
+package libyear
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nieomylnieja/go-libyear/internal"
+)
+
+func TestParseAggregation(t *testing.T) {
+	for input, expected := range map[string]Aggregation{
+		"sum":    {Strategy: AggregationSum},
+		"mean":   {Strategy: AggregationMean},
+		"median": {Strategy: AggregationMedian},
+		"max":    {Strategy: AggregationMax},
+		"p90":    {Strategy: AggregationPercentile, Percentile: 90},
+		"p99.5":  {Strategy: AggregationPercentile, Percentile: 99.5},
+	} {
+		aggregation, err := ParseAggregation(input)
+		require.NoError(t, err)
+		assert.Equal(t, expected, aggregation)
+		assert.Equal(t, input, aggregation.String())
+	}
+	for _, input := range []string{"", "avg", "p0", "p101", "px"} {
+		_, err := ParseAggregation(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestAggregation_Aggregate(t *testing.T) {
+	values := []float64{4, 1, 3, 2}
+	tests := map[string]struct {
+		Aggregation Aggregation
+		Weights     []float64
+		Expected    float64
+	}{
+		"sum": {
+			Aggregation: Aggregation{},
+			Weights:     []float64{1, 1, 1, 1},
+			Expected:    10,
+		},
+		"weighted sum": {
+			Aggregation: Aggregation{Strategy: AggregationSum},
+			Weights:     []float64{0.5, 1, 0, 2},
+			Expected:    7,
+		},
+		"mean": {
+			Aggregation: Aggregation{Strategy: AggregationMean},
+			Weights:     []float64{1, 1, 1, 1},
+			Expected:    2.5,
+		},
+		"weighted mean": {
+			Aggregation: Aggregation{Strategy: AggregationMean},
+			Weights:     []float64{2, 0, 1, 1},
+			Expected:    3.25,
+		},
+		"median": {
+			Aggregation: Aggregation{Strategy: AggregationMedian},
+			Weights:     []float64{1, 1, 1, 1},
+			Expected:    2.5,
+		},
+		"weighted median": {
+			Aggregation: Aggregation{Strategy: AggregationMedian},
+			Weights:     []float64{3, 1, 1, 1},
+			Expected:    3.5,
+		},
+		"p75": {
+			Aggregation: Aggregation{Strategy: AggregationPercentile, Percentile: 75},
+			Weights:     []float64{1, 1, 1, 1},
+			Expected:    3.5,
+		},
+		"p90": {
+			Aggregation: Aggregation{Strategy: AggregationPercentile, Percentile: 90},
+			Weights:     []float64{1, 1, 1, 1},
+			Expected:    4,
+		},
+		"max": {
+			Aggregation: Aggregation{Strategy: AggregationMax},
+			Weights:     []float64{1, 1, 1, 1},
+			Expected:    4,
+		},
+		"max ignores zero weights": {
+			Aggregation: Aggregation{Strategy: AggregationMax},
+			Weights:     []float64{0, 1, 1, 1},
+			Expected:    3,
+		},
+		"zero weights": {
+			Aggregation: Aggregation{Strategy: AggregationMedian},
+			Weights:     []float64{0, 0, 0, 0},
+			Expected:    0,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.InDelta(t, test.Expected, test.Aggregation.aggregate(values, test.Weights), 1e-9)
+		})
+	}
+}
+
+func TestCommand_Aggregate(t *testing.T) {
+	newModules := func() []*internal.Module {
+		return []*internal.Module{
+			{
+				Path:         "github.com/a/direct",
+				Libyear:      2,
+				ReleasesDiff: 4,
+				VersionsDiff: internal.VersionsDiff{1, 2, 0},
+				Usage:        &internal.PackageUsage{Packages: 3},
+			},
+			{
+				Path:         "github.com/b/indirect",
+				Indirect:     true,
+				Libyear:      1,
+				ReleasesDiff: 1,
+				VersionsDiff: internal.VersionsDiff{0, 1, 3},
+				Usage:        &internal.PackageUsage{Packages: 1},
+			},
+			{
+				Path:         "github.com/c/upstream",
+				Libyear:      10,
+				ReleasesDiff: 10,
+				Replace:      &internal.Replace{Kind: internal.ReplaceUpstream},
+			},
+		}
+	}
+	tests := map[string]struct {
+		Command         Command
+		ExpectedLibyear float64
+		ExpectedRelease int
+		ExpectedDiff    internal.VersionsDiff
+		Description     string
+	}{
+		"default sum": {
+			ExpectedLibyear: 3,
+			ExpectedRelease: 5,
+			ExpectedDiff:    internal.VersionsDiff{1, 3, 3},
+		},
+		"mean weighted by direct": {
+			Command:         Command{aggregation: Aggregation{Strategy: AggregationMean}, weighting: WeightingDirect},
+			ExpectedLibyear: 2.5 / 1.5,
+			ExpectedRelease: 3,
+			ExpectedDiff:    internal.VersionsDiff{1, 2, 1},
+			Description:     "mean, weighted by direct",
+		},
+		"sum weighted by packages": {
+			Command:         Command{weighting: WeightingPackages},
+			ExpectedLibyear: 7,
+			ExpectedRelease: 13,
+			ExpectedDiff:    internal.VersionsDiff{3, 7, 3},
+			Description:     "sum, weighted by packages",
+		},
+		"max weighted by config": {
+			Command: Command{
+				aggregation: Aggregation{Strategy: AggregationMax},
+				weights:     map[string]float64{"github.com/a/direct": 0},
+			},
+			ExpectedLibyear: 1,
+			ExpectedRelease: 1,
+			ExpectedDiff:    internal.VersionsDiff{0, 1, 3},
+			Description:     "max, weighted by config",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mainModule := &internal.Module{}
+
+			test.Command.aggregate(mainModule, newModules())
+
+			assert.InDelta(t, test.ExpectedLibyear, mainModule.Libyear, 1e-9)
+			assert.Equal(t, test.ExpectedRelease, mainModule.ReleasesDiff)
+			assert.Equal(t, test.ExpectedDiff, mainModule.VersionsDiff)
+			assert.Equal(t, test.Description, test.Command.describeAggregation())
+		})
+	}
+}
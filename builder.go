@@ -1,16 +1,20 @@
 package libyear
 
 import (
-	"path/filepath"
+	"log/slog"
+	"net/http"
 	"time"
 
+	"github.com/pkg/errors"
+
 	"github.com/nieomylnieja/go-libyear/internal"
 )
 
 func NewCommandBuilder(source Source, output Output) CommandBuilder {
 	return CommandBuilder{
-		source: source,
-		output: output,
+		source:     source,
+		output:     output,
+		httpConfig: internal.DefaultHTTPConfig(),
 	}
 }
 
@@ -20,15 +24,173 @@ type CommandBuilder struct {
 	repo          ModulesRepo
 	fallback      VersionsGetter
 	withCache     bool
-	cacheFilePath string
+	cacheConfig   internal.CacheConfig
+	goSumFile     string
+	goSumDB       string
 	opts          Option
 	vcsRegistry   *VCSRegistry
 	ageLimit      time.Time
+	goReleasesSrc string
+	vulnDBSrc     string
+	activityN     int
+	inactivity    time.Duration
+	aggregation   Aggregation
+	weighting     Weighting
+	weights       map[string]float64
+	projectDir    string
+	prereleases   PrereleasePolicy
+	prereleaseOvr map[string]PrereleasePolicy
+	httpClient    *http.Client
+	httpConfig    internal.HTTPConfig
+	goAuth        string
+	bearerTokens  map[string]string
+	concurrency   int
+	proxyLimit    int
+	gitLimit      int
+	logger        *slog.Logger
+	progress      ProgressFunc
 }
 
 func (b CommandBuilder) WithCache(cacheFilePath string) CommandBuilder {
 	b.withCache = true
-	b.cacheFilePath = cacheFilePath
+	b.cacheConfig.FilePath = cacheFilePath
+	return b
+}
+
+// WithCacheBackend enables caching and stores the cached information in the backend.
+// It can be used to share the cache between multiple commands or processes.
+// The settings of [CommandBuilder.WithCacheTTL], [CommandBuilder.WithCacheRefresh]
+// and [CommandBuilder.WithCacheBypass] are respected.
+func (b CommandBuilder) WithCacheBackend(backend CacheBackend) CommandBuilder {
+	b.withCache = true
+	b.cacheConfig.Backend = backend
+	return b
+}
+
+// WithGoSumFile sets the path to the project's go.sum file.
+// The hashes it lists are used to verify the cached go.mod files and the go.mod files
+// fetched with [OptionVerifyChecksums].
+func (b CommandBuilder) WithGoSumFile(path string) CommandBuilder {
+	b.goSumFile = path
+	return b
+}
+
+// WithGoSumDB sets the checksum database used with [OptionVerifyChecksums], it follows the GOSUMDB syntax.
+// Use 'off' to verify go.mod files against go.sum only.
+// By default, GOSUMDB environment variable or [internal.DefaultGoSumDB] is used.
+func (b CommandBuilder) WithGoSumDB(gosumdb string) CommandBuilder {
+	b.goSumDB = gosumdb
+	return b
+}
+
+// WithCacheTTL sets the time after which cached version lists and latest versions are refreshed.
+// By default, [internal.DefaultCacheTTL] is used.
+func (b CommandBuilder) WithCacheTTL(ttl time.Duration) CommandBuilder {
+	b.cacheConfig.TTL = ttl
+	return b
+}
+
+// WithCacheRefresh ignores cached version lists and latest versions and overwrites them with fresh ones.
+func (b CommandBuilder) WithCacheRefresh() CommandBuilder {
+	b.cacheConfig.Refresh = true
+	return b
+}
+
+// WithCacheBypass disables caching of version lists and latest versions,
+// only the immutable information about specific versions is cached.
+func (b CommandBuilder) WithCacheBypass() CommandBuilder {
+	b.cacheConfig.Bypass = true
+	return b
+}
+
+// WithHTTPClient sets the HTTP client used to query GOPROXY, deps.dev, the checksum database and Go releases.
+// Its transport is wrapped with the retries and rate limits configured with [CommandBuilder.WithHTTPRetries]
+// and [CommandBuilder.WithHTTPRateLimit], the client itself is not modified.
+// The client's timeout, if set, covers all retries of a request.
+func (b CommandBuilder) WithHTTPClient(client *http.Client) CommandBuilder {
+	b.httpClient = client
+	return b
+}
+
+// WithHTTPRetries sets the number of retries of failed HTTP requests and the bounds of the exponential backoff.
+// Requests are retried on network errors, 429 and 5xx status codes, respecting Retry-After header.
+// Zero maxRetries disables retries, zero backoffs fall back to the defaults.
+// By default, [internal.DefaultHTTPMaxRetries], [internal.DefaultHTTPMinBackoff]
+// and [internal.DefaultHTTPMaxBackoff] are used.
+func (b CommandBuilder) WithHTTPRetries(maxRetries int, minBackoff, maxBackoff time.Duration) CommandBuilder {
+	b.httpConfig.MaxRetries = maxRetries
+	b.httpConfig.MinBackoff = minBackoff
+	b.httpConfig.MaxBackoff = maxBackoff
+	return b
+}
+
+// WithHTTPRateLimit limits the number of concurrent HTTP requests and requests per second sent to a single host.
+// Zero values disable the respective limit, by default requests are not limited.
+func (b CommandBuilder) WithHTTPRateLimit(maxConcurrent int, requestsPerSecond float64) CommandBuilder {
+	b.httpConfig.MaxConcurrentPerHost = maxConcurrent
+	b.httpConfig.RequestsPerSecondPerHost = requestsPerSecond
+	return b
+}
+
+// WithGoAuth sets the commands providing the credentials of HTTP requests, it follows the GOAUTH syntax,
+// e.g. 'netrc;git /home/user/repo'. Use 'off' to disable reading the credentials.
+// By default, GOAUTH environment variable is used and if it's not set, the credentials are read from .netrc file.
+// Credentials provided by the commands are only sent over HTTPS.
+// Basic auth credentials embedded in GOPROXY URL take precedence over the ones provided by the commands.
+func (b CommandBuilder) WithGoAuth(goAuth string) CommandBuilder {
+	b.goAuth = goAuth
+	return b
+}
+
+// WithBearerToken sets the bearer token sent with HTTP requests to URLs matching the prefix,
+// e.g. 'proxy.example.com/go'. The scheme is ignored, the token is sent over any protocol.
+// If multiple prefixes match the URL, the longest one is used.
+// Bearer tokens take precedence over the credentials provided by [CommandBuilder.WithGoAuth].
+func (b CommandBuilder) WithBearerToken(urlPrefix, token string) CommandBuilder {
+	tokens := make(map[string]string, len(b.bearerTokens)+1)
+	for prefix, t := range b.bearerTokens {
+		tokens[prefix] = t
+	}
+	tokens[urlPrefix] = token
+	b.bearerTokens = tokens
+	return b
+}
+
+// WithConcurrency sets the number of modules processed concurrently.
+// By default, [DefaultConcurrency] is used.
+func (b CommandBuilder) WithConcurrency(concurrency int) CommandBuilder {
+	b.concurrency = concurrency
+	return b
+}
+
+// WithProxyConcurrency limits the number of concurrent GOPROXY requests,
+// if the [ModulesRepo] supports it. Zero means the requests are only limited
+// by [CommandBuilder.WithConcurrency], which is the default.
+// Concurrent requests for the same resource are always sent only once.
+func (b CommandBuilder) WithProxyConcurrency(limit int) CommandBuilder {
+	b.proxyLimit = limit
+	return b
+}
+
+// WithGitConcurrency limits the number of concurrently running git subprocesses
+// used to resolve private modules. Zero means the subprocesses are only limited
+// by [CommandBuilder.WithConcurrency], which is the default.
+// The limit is applied to the [VCSRegistry] set with [CommandBuilder.WithVCSRegistry] as well.
+func (b CommandBuilder) WithGitConcurrency(limit int) CommandBuilder {
+	b.gitLimit = limit
+	return b
+}
+
+// WithLogger sets the logger used to report diagnostics, e.g. warnings about skipped versions
+// or ignored cache entries. By default, [slog.Default] is used.
+func (b CommandBuilder) WithLogger(logger *slog.Logger) CommandBuilder {
+	b.logger = logger
+	return b
+}
+
+// WithProgress sets the function called each time a module is processed by [Command.Run].
+func (b CommandBuilder) WithProgress(progress ProgressFunc) CommandBuilder {
+	b.progress = progress
 	return b
 }
 
@@ -59,44 +221,180 @@ func (b CommandBuilder) WithAgeLimit(limit time.Time) CommandBuilder {
 	return b
 }
 
+// WithGoReleasesSource sets the source of released Go versions list used with [OptionShowToolchain].
+// It can be either a URL or a local file path, the contents must follow go.dev/dl JSON format.
+// By default, https://go.dev/dl/?mode=json&include=all is used.
+func (b CommandBuilder) WithGoReleasesSource(source string) CommandBuilder {
+	b.goReleasesSrc = source
+	return b
+}
+
+// WithVulnerabilityDB sets the path to an offline vulnerability database used with [OptionShowVulnerabilities].
+// It can be either a directory or a zip archive in the format served by vuln.go.dev.
+func (b CommandBuilder) WithVulnerabilityDB(source string) CommandBuilder {
+	b.vulnDBSrc = source
+	return b
+}
+
+// WithActivity configures the release cadence analysis enabled with [OptionShowActivity].
+// The median release interval is calculated from the given number of most recent releases
+// and modules which were not released for longer than inactivityPeriod are flagged as inactive.
+// By default, [DefaultActivityReleases] and [DefaultInactivityPeriod] are used.
+func (b CommandBuilder) WithActivity(releases int, inactivityPeriod time.Duration) CommandBuilder {
+	b.activityN = releases
+	b.inactivity = inactivityPeriod
+	return b
+}
+
+// WithAggregation sets the strategy used to aggregate dependencies' metrics into the main module's metrics.
+// By default, the metrics are summed up.
+func (b CommandBuilder) WithAggregation(aggregation Aggregation) CommandBuilder {
+	b.aggregation = aggregation
+	return b
+}
+
+// WithWeighting sets the strategy used to weight dependencies' metrics during aggregation.
+// [WeightingPackages] requires the project directory to be set with [CommandBuilder.WithProjectDir].
+func (b CommandBuilder) WithWeighting(weighting Weighting) CommandBuilder {
+	b.weighting = weighting
+	return b
+}
+
+// WithWeights sets user-defined weights of the modules, keyed by module path.
+// They take precedence over the weights calculated with [CommandBuilder.WithWeighting].
+func (b CommandBuilder) WithWeights(weights map[string]float64) CommandBuilder {
+	b.weights = weights
+	return b
+}
+
+// WithProjectDir sets the directory of the analyzed project,
+// which is required to analyze the project's packages.
+func (b CommandBuilder) WithProjectDir(dir string) CommandBuilder {
+	b.projectDir = dir
+	return b
+}
+
+// WithPrereleasePolicy sets the policy deciding whether prereleases can be selected as latest versions.
+// Overrides are keyed by module path prefix patterns, as in GOPRIVATE, and take precedence over the policy.
+// By default, prereleases are only selected if the current version is a prerelease.
+func (b CommandBuilder) WithPrereleasePolicy(
+	policy PrereleasePolicy,
+	overrides map[string]PrereleasePolicy,
+) CommandBuilder {
+	b.prereleases = policy
+	b.prereleaseOvr = overrides
+	return b
+}
+
 func (b CommandBuilder) Build() (*Command, error) {
+	if b.opts&OptionShowVulnerabilities != 0 && b.vulnDBSrc == "" {
+		return nil, errors.New("vulnerability database must be provided in order to show vulnerabilities")
+	}
+	if b.httpConfig.MaxRetries < 0 ||
+		b.httpConfig.MaxConcurrentPerHost < 0 ||
+		b.httpConfig.RequestsPerSecondPerHost < 0 {
+		return nil, errors.New("HTTP retries and rate limits must not be negative")
+	}
+	if b.concurrency < 0 || b.proxyLimit < 0 || b.gitLimit < 0 {
+		return nil, errors.New("concurrency limits must not be negative")
+	}
+	if b.goSumFile != "" {
+		goSum, err := internal.ReadGoSumFile(b.goSumFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read go.sum file")
+		}
+		b.cacheConfig.GoSum = goSum
+	}
+	b.cacheConfig.Logger = b.logger
+	auth, err := internal.NewHTTPAuthFromEnv(b.goAuth, b.logger)
+	if err != nil {
+		return nil, err
+	}
+	for prefix, token := range b.bearerTokens {
+		auth.SetBearerToken(prefix, token)
+	}
+	b.httpConfig.Auth = auth
+	httpClient := internal.NewHTTPClient(b.httpClient, b.httpConfig)
+	// Share initialized HTTP client with sources.
+	if v, ok := b.source.(interface{ SetHTTPClient(client *http.Client) }); ok {
+		v.SetHTTPClient(httpClient)
+	}
 	if b.repo == nil {
-		var err error
 		if b.opts&OptionUseGoList != 0 {
-			b.repo, err = internal.NewGoListExecutor(b.withCache, b.cacheFilePath)
+			b.repo, err = internal.NewGoListExecutor(b.withCache, b.cacheConfig)
 		} else {
-			b.repo, err = internal.NewGoProxyClient(b.withCache, b.cacheFilePath)
+			b.repo, err = internal.NewGoProxyClient(httpClient, b.withCache, b.cacheConfig)
 		}
 		if err != nil {
 			return nil, err
 		}
 	}
+	if v, ok := b.repo.(interface{ SetConcurrencyLimit(limit int) }); ok && b.proxyLimit > 0 {
+		v.SetConcurrencyLimit(b.proxyLimit)
+	}
 	if b.fallback == nil {
-		b.fallback = internal.NewDepsDevClient()
+		b.fallback = internal.NewDepsDevClient(httpClient)
 	}
 	// Share initialized ModulesRepo with sources.
 	if v, ok := b.source.(interface{ SetModulesRepo(repo ModulesRepo) }); ok {
 		v.SetModulesRepo(b.repo)
 	}
 	if b.vcsRegistry == nil {
-		cacheBase, err := internal.GetDefaultCacheBasePath()
+		cacheDir, err := DefaultVCSCacheDir()
 		if err != nil {
 			return nil, err
 		}
-		cacheDir := filepath.Join(cacheBase, "vcs")
 		b.vcsRegistry = NewVCSRegistry(cacheDir)
 	}
+	if b.gitLimit > 0 {
+		b.vcsRegistry.SetConcurrencyLimit(b.gitLimit)
+	}
 	// Share initialized VCSRegistry with sources.
 	if v, ok := b.source.(interface{ SetVCSRegistry(registry *VCSRegistry) }); ok {
 		v.SetVCSRegistry(b.vcsRegistry)
 	}
+	var checksums *internal.ModFileVerifier
+	if b.opts&OptionVerifyChecksums != 0 {
+		sumDB, err := internal.NewSumDBFromEnv(httpClient, b.goSumDB)
+		if err != nil {
+			return nil, err
+		}
+		if sumDB == nil && b.cacheConfig.GoSum == nil {
+			return nil, errors.New("either go.sum file or checksum database must be provided in order to verify checksums")
+		}
+		checksums = &internal.ModFileVerifier{GoSum: b.cacheConfig.GoSum, SumDB: sumDB}
+		// Share initialized verifier with sources.
+		if v, ok := b.source.(interface {
+			SetModFileVerifier(verifier *internal.ModFileVerifier)
+		}); ok {
+			v.SetModFileVerifier(checksums)
+		}
+	}
+	var packages PackageUsageLister
+	if b.projectDir != "" {
+		packages = internal.NewGoPackagesLister(b.projectDir)
+	}
 	return &Command{
-		source:           b.source,
-		output:           b.output,
-		repo:             b.repo,
-		fallbackVersions: b.fallback,
-		opts:             b.opts,
-		vcs:              b.vcsRegistry,
-		ageLimit:         b.ageLimit,
+		source:              b.source,
+		output:              b.output,
+		repo:                b.repo,
+		fallbackVersions:    b.fallback,
+		opts:                b.opts,
+		vcs:                 b.vcsRegistry,
+		ageLimit:            b.ageLimit,
+		goReleases:          internal.NewGoReleasesClient(httpClient, b.goReleasesSrc),
+		vulnDB:              internal.NewVulnDB(b.vulnDBSrc),
+		activityReleases:    b.activityN,
+		inactivityPeriod:    b.inactivity,
+		aggregation:         b.aggregation,
+		weighting:           b.weighting,
+		weights:             b.weights,
+		packages:            packages,
+		prereleases:         b.prereleases,
+		prereleaseOverrides: b.prereleaseOvr,
+		checksums:           checksums,
+		concurrency:         b.concurrency,
+		logger:              b.logger,
+		progress:            b.progress,
 	}, nil
 }
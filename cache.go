@@ -0,0 +1,77 @@
+package libyear
+
+import (
+	"github.com/nieomylnieja/go-libyear/internal"
+)
+
+// CacheBackend stores the modules' information cached by [ModulesRepo] implementations
+// created with [CommandBuilder.WithCacheBackend].
+// It must be safe for concurrent use.
+// Custom implementations can be verified with the conformance tests of cachetest package.
+// The caching policy, like refreshing stale version lists, is implemented on top of the backend,
+// which only has to store and retrieve the entries.
+type CacheBackend interface {
+	// Get returns the entry stored under the key.
+	Get(key CacheKey) (entry *CacheEntry, found bool, err error)
+	// Put stores the entry, replacing the one stored under the same key.
+	Put(entry *CacheEntry) error
+}
+
+// CacheKey identifies [CacheEntry].
+// Version is only set for [CacheEntryModule] and [CacheEntryModFile] entries.
+type CacheKey = internal.CacheKey
+
+// CacheEntry is a single piece of cached information about a module.
+// Use [CacheEntry.Key] to obtain the key under which it's stored.
+type CacheEntry = internal.CacheEntry
+
+// CacheEntryKind distinguishes the kinds of cached information.
+type CacheEntryKind = internal.CacheEntryKind
+
+const (
+	// CacheEntryModule holds the release time of a specific module version, it never changes.
+	CacheEntryModule = internal.CacheEntryModule
+	// CacheEntryVersions holds the list of module's versions.
+	CacheEntryVersions = internal.CacheEntryVersions
+	// CacheEntryLatest holds the latest version of the module and its release time.
+	CacheEntryLatest = internal.CacheEntryLatest
+	// CacheEntryModFile holds the go.mod file of a specific module version, it never changes.
+	CacheEntryModFile = internal.CacheEntryModFile
+)
+
+// NewFileCacheBackend creates [CacheBackend] which stores the entries in a JSON lines file.
+// This is the default backend, the file can be shared by multiple processes.
+// If filePath is empty, the default location is used.
+// nolint: ireturn
+func NewFileCacheBackend(filePath string) (CacheBackend, error) {
+	cacheFile, err := internal.OpenCacheFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	backend, err := internal.NewFileCacheBackend(cacheFile.Path)
+	if err != nil {
+		return nil, err
+	}
+	return backend, nil
+}
+
+// NewLRUCacheBackend creates in-memory [CacheBackend] which stores at most size entries,
+// evicting the least recently used ones.
+// If size is not positive, [internal.DefaultLRUCacheSize] is used.
+// nolint: ireturn
+func NewLRUCacheBackend(size int) CacheBackend {
+	return internal.NewLRUCacheBackend(size)
+}
+
+// NewDirCacheBackend creates [CacheBackend] which stores each entry in a separate file,
+// grouped in a directory per module.
+// Unlike [NewFileCacheBackend], the entries are not loaded into memory upfront and entries
+// written by other processes are immediately visible.
+// nolint: ireturn
+func NewDirCacheBackend(dir string) (CacheBackend, error) {
+	backend, err := internal.NewDirCacheBackend(dir)
+	if err != nil {
+		return nil, err
+	}
+	return backend, nil
+}
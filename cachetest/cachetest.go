@@ -0,0 +1,141 @@
+// Package cachetest implements conformance tests for [golibyear.CacheBackend] implementations.
+package cachetest
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	golibyear "github.com/nieomylnieja/go-libyear"
+)
+
+// TestBackend verifies the behavior expected from every [golibyear.CacheBackend] implementation.
+// The newBackend function is called for each subtest and must return an empty backend.
+func TestBackend(t *testing.T, newBackend func(t *testing.T) golibyear.CacheBackend) {
+	fetchedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	moduleEntry := &golibyear.CacheEntry{
+		Kind:      golibyear.CacheEntryModule,
+		Path:      "github.com/Masterminds/semver",
+		Version:   semver.MustParse("v1.5.0"),
+		Time:      time.Date(2019, 9, 11, 0, 0, 0, 0, time.UTC),
+		FetchedAt: fetchedAt,
+	}
+	versionsEntry := &golibyear.CacheEntry{
+		Kind:      golibyear.CacheEntryVersions,
+		Path:      "github.com/Masterminds/semver",
+		Versions:  []*semver.Version{semver.MustParse("v1.4.0"), semver.MustParse("v1.5.0")},
+		FetchedAt: fetchedAt,
+	}
+	latestEntry := &golibyear.CacheEntry{
+		Kind:      golibyear.CacheEntryLatest,
+		Path:      "github.com/Masterminds/semver",
+		Version:   semver.MustParse("v1.5.0"),
+		Time:      time.Date(2019, 9, 11, 0, 0, 0, 0, time.UTC),
+		FetchedAt: fetchedAt,
+	}
+	modFileEntry := &golibyear.CacheEntry{
+		Kind:      golibyear.CacheEntryModFile,
+		Path:      "github.com/Masterminds/semver",
+		Version:   semver.MustParse("v1.5.0"),
+		ModFile:   []byte("module github.com/Masterminds/semver\n"),
+		Sum:       "h1:H65muMkzWKEuNDnfl9d70GUjFniHKHRKFPGBuPWKhl4=",
+		FetchedAt: fetchedAt,
+	}
+	allEntries := []*golibyear.CacheEntry{moduleEntry, versionsEntry, latestEntry, modFileEntry}
+
+	t.Run("missing entry", func(t *testing.T) {
+		backend := newBackend(t)
+		for _, entry := range allEntries {
+			_, found, err := backend.Get(entry.Key())
+			require.NoError(t, err)
+			assert.False(t, found)
+		}
+	})
+	t.Run("put and get", func(t *testing.T) {
+		backend := newBackend(t)
+		for _, entry := range allEntries {
+			require.NoError(t, backend.Put(entry))
+		}
+		for _, entry := range allEntries {
+			loaded, found, err := backend.Get(entry.Key())
+			require.NoError(t, err)
+			require.True(t, found, entry.Kind)
+			assertCacheEntriesEqual(t, entry, loaded)
+		}
+	})
+	t.Run("keys are distinct", func(t *testing.T) {
+		backend := newBackend(t)
+		require.NoError(t, backend.Put(moduleEntry))
+		otherVersion := semver.MustParse("v1.4.0")
+		_, found, err := backend.Get(golibyear.CacheKey{
+			Kind:    golibyear.CacheEntryModule,
+			Path:    moduleEntry.Path,
+			Version: otherVersion,
+		})
+		require.NoError(t, err)
+		assert.False(t, found, "other version")
+		otherPath := "github.com/masterminds/semver"
+		_, found, err = backend.Get(golibyear.CacheKey{
+			Kind:    golibyear.CacheEntryModule,
+			Path:    otherPath,
+			Version: moduleEntry.Version,
+		})
+		require.NoError(t, err)
+		assert.False(t, found, "module paths are case-sensitive")
+		for _, kind := range []golibyear.CacheEntryKind{golibyear.CacheEntryLatest, golibyear.CacheEntryModFile} {
+			_, found, err = backend.Get(golibyear.CacheKey{Kind: kind, Path: moduleEntry.Path, Version: moduleEntry.Version})
+			require.NoError(t, err)
+			assert.False(t, found, "other kind: %s", kind)
+		}
+	})
+	t.Run("put replaces entry", func(t *testing.T) {
+		backend := newBackend(t)
+		require.NoError(t, backend.Put(versionsEntry))
+		refreshed := *versionsEntry
+		refreshed.Versions = append(refreshed.Versions, semver.MustParse("v1.6.0"))
+		refreshed.FetchedAt = fetchedAt.Add(time.Hour)
+		require.NoError(t, backend.Put(&refreshed))
+
+		loaded, found, err := backend.Get(versionsEntry.Key())
+		require.NoError(t, err)
+		require.True(t, found)
+		assertCacheEntriesEqual(t, &refreshed, loaded)
+	})
+	t.Run("concurrent access", func(t *testing.T) {
+		backend := newBackend(t)
+		var wg sync.WaitGroup
+		for i := range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := range 20 {
+					entry := *moduleEntry
+					entry.Version = semver.MustParse(fmt.Sprintf("v%d.%d.0", i, j))
+					assert.NoError(t, backend.Put(&entry))
+					_, found, err := backend.Get(entry.Key())
+					assert.NoError(t, err)
+					assert.True(t, found)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func assertCacheEntriesEqual(t *testing.T, expected, actual *golibyear.CacheEntry) {
+	t.Helper()
+	assert.Equal(t, expected.Kind, actual.Kind)
+	assert.Equal(t, expected.Path, actual.Path)
+	assert.Equal(t, fmt.Sprint(expected.Version), fmt.Sprint(actual.Version))
+	assert.True(t, expected.Time.Equal(actual.Time), "time: expected %s, got %s", expected.Time, actual.Time)
+	assert.Equal(t, fmt.Sprint(expected.Versions), fmt.Sprint(actual.Versions))
+	assert.Equal(t, expected.ModFile, actual.ModFile)
+	assert.Equal(t, expected.Sum, actual.Sum)
+	assert.True(t, expected.FetchedAt.Equal(actual.FetchedAt),
+		"fetched at: expected %s, got %s", expected.FetchedAt, actual.FetchedAt)
+}
@@ -0,0 +1,34 @@
+package cachetest_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	golibyear "github.com/nieomylnieja/go-libyear"
+	"github.com/nieomylnieja/go-libyear/cachetest"
+)
+
+func TestBackends(t *testing.T) {
+	backends := map[string]func(t *testing.T) golibyear.CacheBackend{
+		"file": func(t *testing.T) golibyear.CacheBackend {
+			backend, err := golibyear.NewFileCacheBackend(filepath.Join(t.TempDir(), "modules"))
+			require.NoError(t, err)
+			return backend
+		},
+		"lru": func(*testing.T) golibyear.CacheBackend {
+			return golibyear.NewLRUCacheBackend(0)
+		},
+		"dir": func(t *testing.T) golibyear.CacheBackend {
+			backend, err := golibyear.NewDirCacheBackend(t.TempDir())
+			require.NoError(t, err)
+			return backend
+		},
+	}
+	for name, newBackend := range backends {
+		t.Run(name, func(t *testing.T) {
+			cachetest.TestBackend(t, newBackend)
+		})
+	}
+}
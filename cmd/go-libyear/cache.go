@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+
+	"github.com/nieomylnieja/go-libyear/internal"
+)
+
+var cacheCommand = &cli.Command{
+	Name:  "cache",
+	Usage: "Manage the modules cache file",
+	Flags: []cli.Flag{flagCacheManagementFile},
+	Subcommands: []*cli.Command{
+		{
+			Name:   "stats",
+			Usage:  "Print the number of entries, file size and the hit rate of the last run",
+			Action: cacheStats,
+		},
+		{
+			Name:  "prune",
+			Usage: "Remove entries fetched before the specified age and/or matching module patterns",
+			Flags: []cli.Flag{
+				flagCachePruneOlderThan,
+				flagCachePruneModule,
+			},
+			Action: cachePrune,
+		},
+		{
+			Name:   "compact",
+			Usage:  "Rewrite the cache file without duplicated entries",
+			Action: cacheCompact,
+		},
+		{
+			Name:      "export",
+			Usage:     "Export the cache entries to a file or stdout if no file is provided",
+			ArgsUsage: "[file]",
+			Action:    cacheExport,
+		},
+		{
+			Name:      "import",
+			Usage:     "Merge the entries produced by 'cache export' into the cache file, use '-' to read from stdin",
+			ArgsUsage: "<file>",
+			Action:    cacheImport,
+		},
+		{
+			Name:   "verify",
+			Usage:  "Re-check a random sample of cached modules' versions against GOPROXY",
+			Flags:  []cli.Flag{flagCacheVerifySample},
+			Action: cacheVerify,
+		},
+	},
+}
+
+func cacheStats(cliCtx *cli.Context) error {
+	cacheFile, err := openCacheFile(cliCtx)
+	if err != nil {
+		return err
+	}
+	stats, err := cacheFile.Stats()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("file: %s\n", cacheFile.Path)
+	fmt.Printf("size: %d bytes\n", stats.Size)
+	fmt.Printf("entries: %d\n", stats.Entries)
+	fmt.Printf("  modules' versions: %d\n", stats.Modules)
+	fmt.Printf("  version lists: %d\n", stats.VersionLists)
+	fmt.Printf("  latest versions: %d\n", stats.Latest)
+	fmt.Printf("  go.mod files: %d\n", stats.ModFiles)
+	fmt.Printf("  duplicates: %d\n", stats.Duplicates)
+	fmt.Printf("  corrupted: %d\n", stats.Corrupted)
+	if stats.LastRun == nil {
+		fmt.Println("last run: no stats recorded")
+		return nil
+	}
+	fmt.Printf("last run: %s, hits: %d, misses: %d, hit rate: %.1f%%\n",
+		stats.LastRun.Time.Format(time.RFC3339),
+		stats.LastRun.Hits,
+		stats.LastRun.Misses,
+		stats.LastRun.HitRate()*100)
+	return nil
+}
+
+func cachePrune(cliCtx *cli.Context) error {
+	cacheFile, err := openCacheFile(cliCtx)
+	if err != nil {
+		return err
+	}
+	removed, err := cacheFile.Prune(flagCachePruneOlderThan.Get(cliCtx), flagCachePruneModule.Get(cliCtx))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("removed %d entries\n", removed)
+	return nil
+}
+
+func cacheCompact(cliCtx *cli.Context) error {
+	cacheFile, err := openCacheFile(cliCtx)
+	if err != nil {
+		return err
+	}
+	removed, err := cacheFile.Compact()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("removed %d duplicated entries\n", removed)
+	return nil
+}
+
+func cacheExport(cliCtx *cli.Context) error {
+	cacheFile, err := openCacheFile(cliCtx)
+	if err != nil {
+		return err
+	}
+	if cliCtx.Args().Len() == 0 {
+		return cacheFile.Export(os.Stdout)
+	}
+	// #nosec G304
+	f, err := os.Create(cliCtx.Args().First())
+	if err != nil {
+		return err
+	}
+	if err = cacheFile.Export(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func cacheImport(cliCtx *cli.Context) error {
+	if cliCtx.Args().Len() != 1 {
+		return errors.New("expected exactly one argument: file to import or '-' to read from stdin")
+	}
+	cacheFile, err := openCacheFile(cliCtx)
+	if err != nil {
+		return err
+	}
+	var r io.Reader = os.Stdin
+	if path := cliCtx.Args().First(); path != "-" {
+		// #nosec G304
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		r = f
+	}
+	imported, err := cacheFile.Import(r)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("imported %d entries\n", imported)
+	return nil
+}
+
+func cacheVerify(cliCtx *cli.Context) error {
+	cacheFile, err := openCacheFile(cliCtx)
+	if err != nil {
+		return err
+	}
+	client, err := internal.NewGoProxyClient(nil, false, internal.CacheConfig{})
+	if err != nil {
+		return err
+	}
+	verified, mismatches, err := cacheFile.Verify(client.GetInfo, flagCacheVerifySample.Get(cliCtx))
+	if err != nil {
+		return err
+	}
+	for _, mismatch := range mismatches {
+		if mismatch.Err != nil {
+			fmt.Printf("%s@v%s: failed to fetch: %v\n", mismatch.Path, mismatch.Version, mismatch.Err)
+			continue
+		}
+		fmt.Printf("%s@v%s: cached %s, upstream %s\n", mismatch.Path, mismatch.Version,
+			mismatch.Cached.Format(time.RFC3339), mismatch.Upstream.Format(time.RFC3339))
+	}
+	fmt.Printf("verified %d entries, %d mismatched\n", verified, len(mismatches))
+	if len(mismatches) > 0 {
+		return errors.New("cache verification failed")
+	}
+	return nil
+}
+
+func openCacheFile(cliCtx *cli.Context) (internal.CacheFile, error) {
+	return internal.OpenCacheFile(flagCacheManagementFile.Get(cliCtx))
+}
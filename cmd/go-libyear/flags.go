@@ -8,22 +8,35 @@ import (
 	"github.com/urfave/cli/v2"
 
 	golibyear "github.com/nieomylnieja/go-libyear"
+	"github.com/nieomylnieja/go-libyear/internal"
 )
 
 const (
 	categorySource = "Source:"
 	categoryOutput = "Output:"
 	categoryCache  = "Cache:"
+	categoryHTTP   = "HTTP:"
 )
 
 var flagToOption = map[string]golibyear.Option{
-	flagIndirect.Name:              golibyear.OptionIncludeIndirect,
-	flagSkipFresh.Name:             golibyear.OptionSkipFresh,
-	flagReleases.Name:              golibyear.OptionShowReleases,
-	flagVersions.Name:              golibyear.OptionShowVersions,
-	flagUseGoList.Name:             golibyear.OptionUseGoList,
-	flagFindLatestMajor.Name:       golibyear.OptionFindLatestMajor,
-	flagNoLibyearCompensation.Name: golibyear.OptionNoLibyearCompensation,
+	flagIndirect.Name:                  golibyear.OptionIncludeIndirect,
+	flagSkipFresh.Name:                 golibyear.OptionSkipFresh,
+	flagReleases.Name:                  golibyear.OptionShowReleases,
+	flagVersions.Name:                  golibyear.OptionShowVersions,
+	flagUseGoList.Name:                 golibyear.OptionUseGoList,
+	flagFindLatestMajor.Name:           golibyear.OptionFindLatestMajor,
+	flagNoLibyearCompensation.Name:     golibyear.OptionNoLibyearCompensation,
+	flagRetracted.Name:                 golibyear.OptionShowRetracted,
+	flagDeprecated.Name:                golibyear.OptionShowDeprecated,
+	flagFailOnRetractedDeprecated.Name: golibyear.OptionFailOnRetractedOrDeprecated,
+	flagToolchain.Name:                 golibyear.OptionShowToolchain,
+	flagActivity.Name:                  golibyear.OptionShowActivity,
+	flagUsage.Name:                     golibyear.OptionShowUsage,
+	flagProductionOnly.Name:            golibyear.OptionSkipNonProduction,
+	flagSeparateTools.Name:             golibyear.OptionSeparateTools,
+	flagExcludeTools.Name:              golibyear.OptionExcludeTools,
+	flagMigrations.Name:                golibyear.OptionShowMigrations,
+	flagVerifyChecksums.Name:           golibyear.OptionVerifyChecksums,
 }
 
 var (
@@ -61,18 +74,140 @@ var (
 		Category:    categoryCache,
 		Action:      useOnlyWith[cli.Path]("cache-file-path", flagCache.Name),
 	}
+	flagCacheTTL = &cli.DurationFlag{
+		Name:     "cache-ttl",
+		Usage:    "Refresh cached version lists and latest versions older than the specified duration",
+		Value:    internal.DefaultCacheTTL,
+		Category: categoryCache,
+		Action:   useOnlyWith[time.Duration]("cache-ttl", flagCache.Name),
+	}
+	flagCacheRefresh = &cli.BoolFlag{
+		Name:     "cache-refresh",
+		Usage:    "Ignore cached version lists and latest versions and overwrite them with fresh ones",
+		Category: categoryCache,
+		Action:   useOnlyWith[bool]("cache-refresh", flagCache.Name),
+	}
+	flagCacheBypass = &cli.BoolFlag{
+		Name:     "cache-bypass",
+		Usage:    "Do not cache version lists and latest versions, only information about specific versions is cached",
+		Category: categoryCache,
+		Action:   useOnlyWith[bool]("cache-bypass", flagCache.Name),
+	}
+	flagCacheManagementFile = &cli.PathFlag{
+		Name:        "file",
+		Aliases:     []string{"f"},
+		Usage:       "Manage the specified cache file",
+		DefaultText: flagCacheFilePath.DefaultText,
+	}
+	flagCachePruneOlderThan = &cli.DurationFlag{
+		Name:  "older-than",
+		Usage: "Remove entries fetched more than the specified duration ago",
+	}
+	flagCachePruneModule = &cli.StringSliceFlag{
+		Name: "module",
+		Usage: "Remove entries of modules matching the pattern, can be used multiple times; " +
+			"patterns follow GOPRIVATE syntax, e.g. github.com/nieomylnieja/*",
+	}
+	flagCacheVerifySample = &cli.IntFlag{
+		Name:  "sample",
+		Value: 10,
+		Usage: "Verify a random sample of the specified size, 0 verifies all entries",
+	}
 	flagVCSCacheDir = &cli.PathFlag{
 		Name:        "vcs-cache-dir",
 		Usage:       "Use custom cache directory for VCS modules (downloaded due to GOPRIVATE settings)",
 		DefaultText: "$XDG_CACHE_HOME/go-libyear/vcs or $HOME/.cache/go-libyear/vcs",
 		Category:    categoryCache,
 	}
+	flagVCSLightweight = &cli.BoolFlag{
+		Name: "vcs-lightweight",
+		Usage: "List VCS modules' versions without cloning whole repositories; " +
+			"bare, blobless clones are created only when release dates or go.mod files are needed",
+		Category: categoryCache,
+	}
 	flagTimeout = &cli.DurationFlag{
 		Name:    "timeout",
 		Aliases: []string{"t"},
 		Value:   1 * time.Minute,
 		Usage:   "Set timeout for the command",
 	}
+	flagConcurrency = &cli.IntFlag{
+		Name:  "concurrency",
+		Usage: "Number of modules processed concurrently",
+		Value: golibyear.DefaultConcurrency,
+		Action: func(_ *cli.Context, v int) error {
+			if v < 1 {
+				return errors.New("--concurrency must be a positive number")
+			}
+			return nil
+		},
+	}
+	flagProxyConcurrency = &cli.IntFlag{
+		Name:  "proxy-concurrency",
+		Usage: "Limit the number of concurrent GOPROXY requests, 0 means they are only limited by --concurrency",
+	}
+	flagGitConcurrency = &cli.IntFlag{
+		Name: "git-concurrency",
+		Usage: "Limit the number of concurrent git subprocesses resolving private modules, " +
+			"0 means they are only limited by --concurrency",
+	}
+	flagLogLevel = &cli.StringFlag{
+		Name:  "log-level",
+		Usage: "Minimum level of the diagnostics printed to stderr, one of: debug, info, warn, error",
+		Value: "info",
+		Action: func(_ *cli.Context, v string) error {
+			_, err := parseLogLevel(v)
+			return err
+		},
+	}
+	flagLogFormat = &cli.StringFlag{
+		Name:  "log-format",
+		Usage: "Format of the diagnostics printed to stderr, one of: text, json",
+		Value: logFormatText,
+		Action: func(_ *cli.Context, v string) error {
+			return validateLogFormat(v)
+		},
+	}
+	flagHTTPRetries = &cli.IntFlag{
+		Name:     "http-retries",
+		Usage:    "Retry failed GOPROXY, deps.dev and checksum database requests (network errors, 429 and 5xx) up to N times",
+		Value:    internal.DefaultHTTPMaxRetries,
+		Category: categoryHTTP,
+	}
+	flagHTTPMaxBackoff = &cli.DurationFlag{
+		Name: "http-max-backoff",
+		Usage: "Maximum delay between retries, responses asking to retry later with Retry-After header " +
+			"are not retried",
+		Value:    internal.DefaultHTTPMaxBackoff,
+		Category: categoryHTTP,
+	}
+	flagHTTPHostConcurrency = &cli.IntFlag{
+		Name:     "http-host-concurrency",
+		Usage:    "Limit the number of concurrent requests sent to a single host, 0 means no limit",
+		Category: categoryHTTP,
+	}
+	flagHTTPHostRPS = &cli.Float64Flag{
+		Name:     "http-host-rps",
+		Usage:    "Limit the number of requests per second sent to a single host, 0 means no limit",
+		Category: categoryHTTP,
+	}
+	flagGoAuth = &cli.StringFlag{
+		Name: "goauth",
+		Usage: "Commands providing the credentials of HTTPS requests, following GOAUTH syntax, " +
+			"e.g. 'netrc;git /path/to/repo', defaults to GOAUTH environment variable or 'netrc'",
+		Category: categoryHTTP,
+	}
+	flagBearerToken = &cli.StringSliceFlag{
+		Name: "bearer-token",
+		Usage: "Send bearer token with requests to URLs matching the prefix, defined as 'prefix=token', " +
+			"e.g. 'proxy.example.com/go=secret'; can be repeated",
+		EnvVars:  []string{"GO_LIBYEAR_BEARER_TOKEN"},
+		Category: categoryHTTP,
+		Action: func(_ *cli.Context, v []string) error {
+			_, err := parseBearerTokens(v)
+			return err
+		},
+	}
 	flagUseGoList = &cli.BoolFlag{
 		Name:  "go-list",
 		Usage: "Use 'go list -m' instead of GOPROXY API",
@@ -98,6 +233,122 @@ var (
 		Usage:    "Display the number of major, minor, and patch versions between current and newest versions",
 		Category: categoryOutput,
 	}
+	flagRetracted = &cli.BoolFlag{
+		Name:     "retracted",
+		Usage:    "Display whether the current version was retracted",
+		Category: categoryOutput,
+	}
+	flagDeprecated = &cli.BoolFlag{
+		Name:     "deprecated",
+		Usage:    "Display the deprecation message of deprecated modules",
+		Category: categoryOutput,
+	}
+	flagFailOnRetractedDeprecated = &cli.BoolFlag{
+		Name:  "fail-on-retracted-or-deprecated",
+		Usage: "Exit with non-zero code if any retracted version or deprecated module was detected",
+	}
+	flagVerifyChecksums = &cli.BoolFlag{
+		Name: "verify-checksums",
+		Usage: "Verify fetched go.mod files against the project's go.sum and the checksum database, " +
+			"exit with non-zero code if any mismatch was detected",
+	}
+	flagSumDB = &cli.StringFlag{
+		Name: "sumdb",
+		Usage: "Checksum database used with --verify-checksums, it follows GOSUMDB syntax, " +
+			"use 'off' to only verify against go.sum",
+		DefaultText: "$GOSUMDB or " + internal.DefaultGoSumDB,
+		Action:      useOnlyWith[string]("sumdb", "verify-checksums"),
+	}
+	flagToolchain = &cli.BoolFlag{
+		Name: "toolchain",
+		Usage: "Display Go toolchain freshness based on the go and toolchain directives, " +
+			"including whether it is still supported",
+		Category: categoryOutput,
+	}
+	flagGoReleases = &cli.StringFlag{
+		Name:        "go-releases",
+		Usage:       "Use custom source (URL or file path) of released Go versions in go.dev/dl JSON format",
+		DefaultText: "https://go.dev/dl/?mode=json&include=all",
+		Action:      useOnlyWith[string]("go-releases", flagToolchain.Name),
+	}
+	flagVulnDB = &cli.PathFlag{
+		Name: "vuln-db",
+		Usage: "Display known vulnerabilities, the first version fixing them and libyear to this version, " +
+			"based on offline vulnerability database (directory or zip archive in vuln.go.dev format)",
+		Category: categoryOutput,
+	}
+	flagActivity = &cli.BoolFlag{
+		Name: "activity",
+		Usage: "Display the last release date, days since the last release, " +
+			"median interval between recent releases and whether the module is inactive",
+		Category: categoryOutput,
+	}
+	flagActivityReleases = &cli.IntFlag{
+		Name:     "activity-releases",
+		Usage:    "Number of most recent releases used to calculate the median release interval",
+		Value:    golibyear.DefaultActivityReleases,
+		Category: categoryOutput,
+		Action:   useOnlyWith[int]("activity-releases", flagActivity.Name),
+	}
+	flagInactiveAfter = &cli.DurationFlag{
+		Name:     "inactive-after",
+		Usage:    "Flag modules without any release for longer than this period as inactive",
+		Value:    golibyear.DefaultInactivityPeriod,
+		Category: categoryOutput,
+		Action:   useOnlyWith[time.Duration]("inactive-after", flagActivity.Name),
+	}
+	flagUsage = &cli.BoolFlag{
+		Name: "usage",
+		Usage: "Display the number of each module's packages imported by the project and whether " +
+			"they are only imported by tests; requires path to go.mod in the project's directory",
+		Category: categoryOutput,
+	}
+	flagProductionOnly = &cli.BoolFlag{
+		Name: "production-only",
+		Usage: "Skip modules which are not imported by the project's non-test code, e.g. test or tool dependencies; " +
+			"requires path to go.mod in the project's directory",
+		Category: categoryOutput,
+	}
+	flagSeparateTools = &cli.BoolFlag{
+		Name: "separate-tools",
+		Usage: "Report modules providing tools, declared with 'tool' directives, in a separate section " +
+			"with its own totals; tools are not taken into account in the main module's metrics and checks",
+		Category: categoryOutput,
+	}
+	flagExcludeTools = &cli.BoolFlag{
+		Name:     "exclude-tools",
+		Usage:    "Skip modules providing tools, declared with 'tool' directives",
+		Category: categoryOutput,
+	}
+	flagMigrations = &cli.BoolFlag{
+		Name: "migrations",
+		Usage: "Display the latest version of the module-aware major version path (/vN) " +
+			"which +incompatible modules migrated to",
+		Category: categoryOutput,
+	}
+	flagAggregate = &cli.StringFlag{
+		Name:  "aggregate",
+		Usage: "Aggregate dependencies' metrics in the main module's row using one of: sum, mean, median, max, pNN",
+		Value: string(golibyear.AggregationSum),
+		Action: func(_ *cli.Context, v string) error {
+			_, err := golibyear.ParseAggregation(v)
+			return err
+		},
+	}
+	flagWeighting = &cli.StringFlag{
+		Name: "weighting",
+		Usage: "Weight dependencies' metrics during aggregation using one of: none, direct, packages; " +
+			"packages weighting requires path to go.mod in the project's directory",
+		Value: string(golibyear.WeightingNone),
+		Action: func(_ *cli.Context, v string) error {
+			_, err := golibyear.ParseWeighting(v)
+			return err
+		},
+	}
+	flagWeights = &cli.PathFlag{
+		Name:  "weights",
+		Usage: "Use custom modules' weights during aggregation, defined in a JSON file mapping module paths to weights",
+	}
 	flagFindLatestMajor = &cli.BoolFlag{
 		Name:    "find-latest-major",
 		Aliases: []string{"M"},
@@ -109,6 +360,25 @@ var (
 			"values if latest version was published before current version",
 		Action: useOnlyWith[bool]("no-libyear-compensation", flagFindLatestMajor.Name),
 	}
+	flagPrereleases = &cli.StringFlag{
+		Name: "prereleases",
+		Usage: "Decide whether prereleases can be selected as latest versions using one of: " +
+			"stable (never), current (only if the current version is a prerelease), always",
+		Value: string(golibyear.PrereleaseCurrent),
+		Action: func(_ *cli.Context, v string) error {
+			_, err := golibyear.ParsePrereleasePolicy(v)
+			return err
+		},
+	}
+	flagPrereleasesFor = &cli.StringSliceFlag{
+		Name: "prereleases-for",
+		Usage: "Override prerelease policy for modules matching the path pattern, defined as 'pattern=policy', " +
+			"e.g. 'github.com/nieomylnieja/*=always'; can be repeated",
+		Action: func(_ *cli.Context, v []string) error {
+			_, err := golibyear.ParsePrereleaseOverrides(v)
+			return err
+		},
+	}
 	flagAgeLimit = &cli.TimestampFlag{
 		Name:   "age-limit",
 		Layout: time.RFC3339,
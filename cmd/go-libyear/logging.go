@@ -0,0 +1,64 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// terminalProgress is set if stderr is a terminal, log records are printed above the progress bar.
+var terminalProgress *progressBar
+
+// setupLogging configures the default logger, which writes to stderr, according to the logging flags.
+func setupLogging(cliCtx *cli.Context) error {
+	level, err := parseLogLevel(flagLogLevel.Get(cliCtx))
+	if err != nil {
+		return err
+	}
+	var w io.Writer = os.Stderr
+	if terminalProgress = newProgressBar(os.Stderr); terminalProgress != nil {
+		w = terminalProgress
+	}
+	options := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch flagLogFormat.Get(cliCtx) {
+	case logFormatJSON:
+		handler = slog.NewJSONHandler(w, options)
+	default:
+		// Time is of little use for the command line program's diagnostics.
+		options.ReplaceAttr = func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return attr
+		}
+		handler = slog.NewTextHandler(w, options)
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+func parseLogLevel(value string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		return 0, errors.Errorf("invalid log level '%s', expected one of: debug, info, warn, error", value)
+	}
+	return level, nil
+}
+
+func validateLogFormat(value string) error {
+	switch value {
+	case logFormatText, logFormatJSON:
+		return nil
+	default:
+		return errors.Errorf("invalid log format '%s', expected one of: %s, %s", value, logFormatText, logFormatJSON)
+	}
+}
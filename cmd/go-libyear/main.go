@@ -4,12 +4,11 @@ import (
 	"context"
 	_ "embed"
 	"fmt"
-	"log"
-	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
-	"time"
 
 	golibyear "github.com/nieomylnieja/go-libyear"
 	"github.com/nieomylnieja/go-libyear/internal"
@@ -29,10 +28,10 @@ var (
 var usageText string
 
 func main() {
-	log.SetOutput(os.Stderr)
 	app := &cli.App{
 		Usage:     "Calculate Go module's libyear!",
 		UsageText: usageText,
+		Before:    setupLogging,
 		Action:    run,
 		Name:      internal.ProgramName,
 		Flags: []cli.Flag{
@@ -42,18 +41,67 @@ func main() {
 			flagJSON,
 			flagCache,
 			flagCacheFilePath,
+			flagCacheTTL,
+			flagCacheRefresh,
+			flagCacheBypass,
 			flagVCSCacheDir,
+			flagVCSLightweight,
 			flagTimeout,
+			flagConcurrency,
+			flagProxyConcurrency,
+			flagGitConcurrency,
+			flagLogLevel,
+			flagLogFormat,
+			flagHTTPRetries,
+			flagHTTPMaxBackoff,
+			flagHTTPHostConcurrency,
+			flagHTTPHostRPS,
+			flagGoAuth,
+			flagBearerToken,
 			flagUseGoList,
 			flagIndirect,
 			flagSkipFresh,
 			flagReleases,
 			flagVersions,
+			flagRetracted,
+			flagDeprecated,
+			flagFailOnRetractedDeprecated,
+			flagVerifyChecksums,
+			flagSumDB,
+			flagToolchain,
+			flagGoReleases,
+			flagVulnDB,
+			flagActivity,
+			flagActivityReleases,
+			flagInactiveAfter,
+			flagUsage,
+			flagProductionOnly,
+			flagSeparateTools,
+			flagExcludeTools,
+			flagMigrations,
+			flagAggregate,
+			flagWeighting,
+			flagWeights,
 			flagFindLatestMajor,
 			flagNoLibyearCompensation,
+			flagPrereleases,
+			flagPrereleasesFor,
 			flagAgeLimit,
 			flagVersion,
 		},
+		Commands: []*cli.Command{
+			{
+				Name:      "explain",
+				Usage:     "Explain how the metrics of a single dependency were derived",
+				ArgsUsage: "<module> <path>",
+				Description: "Print the resolution trace of the module required by the go.mod file:\n" +
+					"queried sources, probed paths, considered and filtered versions and calculations' inputs.\n" +
+					"Flags of the main command, placed before 'explain', are respected.\n" +
+					"Example: go-libyear --find-latest-major explain github.com/pkg/errors ./go.mod",
+				Action: explain,
+			},
+			cacheCommand,
+		},
 		Suggest: true,
 	}
 	if err := app.Run(os.Args); err != nil {
@@ -74,14 +122,41 @@ func run(cliCtx *cli.Context) error {
 	if err := validateArgs(cliCtx, stdinUsed); err != nil {
 		return err
 	}
+	cmd, err := newCommand(cliCtx, cliCtx.Args().Get(0), stdinUsed, terminalProgress)
+	if err != nil {
+		return err
+	}
+	return cmd.Run(ctx)
+}
+
+func explain(cliCtx *cli.Context) error {
+	ctx, watch := setupContextHandling(cliCtx)
+	go watch()
+
+	stdinUsed := isStdinUsed()
+	if err := validateExplainArgs(cliCtx, stdinUsed); err != nil {
+		return err
+	}
+	cmd, err := newCommand(cliCtx, cliCtx.Args().Get(1), stdinUsed, nil)
+	if err != nil {
+		return err
+	}
+	return cmd.Explain(ctx, cliCtx.Args().Get(0), os.Stdout)
+}
 
+// newCommand builds the command configured with the flags, progress is optional.
+func newCommand(
+	cliCtx *cli.Context,
+	sourceArg string,
+	stdinUsed bool,
+	progress *progressBar,
+) (*golibyear.Command, error) {
 	var source golibyear.Source
-	sourceArg := cliCtx.Args().Get(0)
 	switch {
 	case cliCtx.IsSet(flagPkg.Name):
 		source = &golibyear.PkgSource{Pkg: sourceArg}
 	case cliCtx.IsSet(flagURL.Name):
-		source = golibyear.URLSource{RawURL: sourceArg, HTTP: http.Client{Timeout: 10 * time.Second}}
+		source = &golibyear.URLSource{RawURL: sourceArg}
 	case stdinUsed:
 		source = golibyear.StdinSource{}
 	default:
@@ -99,27 +174,134 @@ func run(cliCtx *cli.Context) error {
 	}
 
 	builder := golibyear.NewCommandBuilder(source, output)
+	if progress != nil {
+		builder = builder.WithProgress(progress.Report)
+	}
 	if cliCtx.IsSet(flagCache.Name) {
-		builder = builder.WithCache(flagCacheFilePath.Get(cliCtx))
+		builder = builder.WithCache(flagCacheFilePath.Get(cliCtx)).
+			WithCacheTTL(flagCacheTTL.Get(cliCtx))
+		if cliCtx.IsSet(flagCacheRefresh.Name) {
+			builder = builder.WithCacheRefresh()
+		}
+		if cliCtx.IsSet(flagCacheBypass.Name) {
+			builder = builder.WithCacheBypass()
+		}
+	}
+	if cliCtx.IsSet(flagSumDB.Name) {
+		builder = builder.WithGoSumDB(flagSumDB.Get(cliCtx))
+	}
+	// Cached and verified go.mod files are checked against the project's go.sum, if there is one.
+	if cliCtx.IsSet(flagCache.Name) || cliCtx.IsSet(flagVerifyChecksums.Name) {
+		if fileSource, ok := source.(golibyear.FileSource); ok {
+			goSumPath := filepath.Join(filepath.Dir(fileSource.Path), "go.sum")
+			if _, err := os.Stat(goSumPath); err == nil {
+				builder = builder.WithGoSumFile(goSumPath)
+			}
+		}
 	}
 	for flag, option := range flagToOption {
 		if cliCtx.IsSet(flag) {
 			builder = builder.WithOptions(option)
 		}
 	}
-	if cliCtx.IsSet(flagVCSCacheDir.Name) {
-		registry := golibyear.NewVCSRegistry(flagVCSCacheDir.Get(cliCtx))
+	if cliCtx.IsSet(flagVCSCacheDir.Name) || cliCtx.IsSet(flagVCSLightweight.Name) {
+		registry, err := newVCSRegistry(cliCtx)
+		if err != nil {
+			return nil, err
+		}
 		builder = builder.WithVCSRegistry(registry)
 	}
+	builder = builder.
+		WithHTTPRetries(flagHTTPRetries.Get(cliCtx), internal.DefaultHTTPMinBackoff, flagHTTPMaxBackoff.Get(cliCtx)).
+		WithHTTPRateLimit(flagHTTPHostConcurrency.Get(cliCtx), flagHTTPHostRPS.Get(cliCtx)).
+		WithGoAuth(flagGoAuth.Get(cliCtx)).
+		WithConcurrency(flagConcurrency.Get(cliCtx)).
+		WithProxyConcurrency(flagProxyConcurrency.Get(cliCtx)).
+		WithGitConcurrency(flagGitConcurrency.Get(cliCtx))
+	bearerTokens, err := parseBearerTokens(flagBearerToken.Get(cliCtx))
+	if err != nil {
+		return nil, err
+	}
+	for prefix, token := range bearerTokens {
+		builder = builder.WithBearerToken(prefix, token)
+	}
 	if cliCtx.IsSet(flagAgeLimit.Name) {
 		builder = builder.WithAgeLimit(*flagAgeLimit.Get(cliCtx))
 	}
+	if cliCtx.IsSet(flagGoReleases.Name) {
+		builder = builder.WithGoReleasesSource(flagGoReleases.Get(cliCtx))
+	}
+	if cliCtx.IsSet(flagActivity.Name) {
+		builder = builder.WithActivity(flagActivityReleases.Get(cliCtx), flagInactiveAfter.Get(cliCtx))
+	}
+	prereleases, err := golibyear.ParsePrereleasePolicy(flagPrereleases.Get(cliCtx))
+	if err != nil {
+		return nil, err
+	}
+	prereleaseOverrides, err := golibyear.ParsePrereleaseOverrides(flagPrereleasesFor.Get(cliCtx))
+	if err != nil {
+		return nil, err
+	}
+	builder = builder.WithPrereleasePolicy(prereleases, prereleaseOverrides)
+	builder, err = configureAggregation(cliCtx, builder, sourceArg)
+	if err != nil {
+		return nil, err
+	}
+	if cliCtx.IsSet(flagVulnDB.Name) {
+		builder = builder.
+			WithOptions(golibyear.OptionShowVulnerabilities).
+			WithVulnerabilityDB(flagVulnDB.Get(cliCtx))
+	}
+
+	return builder.Build()
+}
 
-	cmd, err := builder.Build()
+func configureAggregation(
+	cliCtx *cli.Context,
+	builder golibyear.CommandBuilder,
+	sourceArg string,
+) (golibyear.CommandBuilder, error) {
+	aggregation, err := golibyear.ParseAggregation(flagAggregate.Get(cliCtx))
 	if err != nil {
-		return err
+		return builder, err
 	}
-	return cmd.Run(ctx)
+	weighting, err := golibyear.ParseWeighting(flagWeighting.Get(cliCtx))
+	if err != nil {
+		return builder, err
+	}
+	builder = builder.WithAggregation(aggregation).WithWeighting(weighting)
+	if isPackagesAnalysisRequired(cliCtx) {
+		builder = builder.WithProjectDir(filepath.Dir(sourceArg))
+	}
+	if cliCtx.IsSet(flagWeights.Name) {
+		weights, err := golibyear.ReadWeightsFile(flagWeights.Get(cliCtx))
+		if err != nil {
+			return builder, err
+		}
+		builder = builder.WithWeights(weights)
+	}
+	return builder, nil
+}
+
+// isPackagesAnalysisRequired reports whether the project's packages have to be loaded.
+func isPackagesAnalysisRequired(cliCtx *cli.Context) bool {
+	return cliCtx.IsSet(flagUsage.Name) ||
+		cliCtx.IsSet(flagProductionOnly.Name) ||
+		flagWeighting.Get(cliCtx) == string(golibyear.WeightingPackages)
+}
+
+func newVCSRegistry(cliCtx *cli.Context) (*golibyear.VCSRegistry, error) {
+	cacheDir := flagVCSCacheDir.Get(cliCtx)
+	if cacheDir == "" {
+		var err error
+		if cacheDir, err = golibyear.DefaultVCSCacheDir(); err != nil {
+			return nil, err
+		}
+	}
+	if cliCtx.IsSet(flagVCSLightweight.Name) {
+		return golibyear.NewLightweightVCSRegistry(cacheDir), nil
+	}
+	return golibyear.NewVCSRegistry(cacheDir), nil
 }
 
 func setupContextHandling(cliCtx *cli.Context) (ctx context.Context, handler func()) {
@@ -156,11 +338,48 @@ func validateArgs(cliCtx *cli.Context, stdinUsed bool) error {
 		return errors.Errorf(
 			"when reading go.mod from stdin no arguments or output related flags should be provided")
 	}
+	return validateFlags(cliCtx, stdinUsed)
+}
+
+func validateExplainArgs(cliCtx *cli.Context, stdinUsed bool) error {
+	if cliCtx.NArg() != 2 && !stdinUsed {
+		return errors.New("invalid number of arguments provided, expected two arguments, module path and path to go.mod")
+	}
+	if stdinUsed && (cliCtx.NArg() != 1 || cliCtx.IsSet(flagURL.Name) || cliCtx.IsSet(flagPkg.Name)) {
+		return errors.Errorf(
+			"when reading go.mod from stdin only module path argument and no output related flags should be provided")
+	}
+	return validateFlags(cliCtx, stdinUsed)
+}
+
+func validateFlags(cliCtx *cli.Context, stdinUsed bool) error {
+	if cliCtx.IsSet(flagFailOnRetractedDeprecated.Name) &&
+		!cliCtx.IsSet(flagRetracted.Name) && !cliCtx.IsSet(flagDeprecated.Name) {
+		return errors.Errorf("--%s flag can only be used in conjunction with --%s or --%s",
+			flagFailOnRetractedDeprecated.Name, flagRetracted.Name, flagDeprecated.Name)
+	}
+	// go.mod files of the dependencies are only fetched to check retractions and deprecations.
+	if cliCtx.IsSet(flagVerifyChecksums.Name) &&
+		!cliCtx.IsSet(flagRetracted.Name) && !cliCtx.IsSet(flagDeprecated.Name) && !cliCtx.IsSet(flagPkg.Name) {
+		return errors.Errorf("--%s flag can only be used in conjunction with --%s, --%s or --%s",
+			flagVerifyChecksums.Name, flagRetracted.Name, flagDeprecated.Name, flagPkg.Name)
+	}
+
+	if isPackagesAnalysisRequired(cliCtx) &&
+		(stdinUsed || cliCtx.IsSet(flagURL.Name) || cliCtx.IsSet(flagPkg.Name)) {
+		return errors.Errorf("--%s, --%s and --%s %s flags can only be used with go.mod file "+
+			"located in the project's directory",
+			flagUsage.Name, flagProductionOnly.Name, flagWeighting.Name, golibyear.WeightingPackages)
+	}
 
 	for _, flags := range [][]string{
 		{flagUseGoList.Name, flagPkg.Name},
+		{flagUseGoList.Name, flagRetracted.Name},
+		{flagUseGoList.Name, flagDeprecated.Name},
 		{flagCSV.Name, flagJSON.Name},
 		{flagURL.Name, flagPkg.Name},
+		{flagSeparateTools.Name, flagExcludeTools.Name},
+		{flagCacheRefresh.Name, flagCacheBypass.Name},
 	} {
 		if err := validateFlagsMutualExclusion(cliCtx, flags); err != nil {
 			return err
@@ -190,3 +409,17 @@ func validateFlagsMutualExclusion(cliCtx *cli.Context, flags []string) error {
 	}
 	return nil
 }
+
+// parseBearerTokens parses 'prefix=token' definitions.
+// The tokens are never included in the returned errors.
+func parseBearerTokens(values []string) (map[string]string, error) {
+	tokens := make(map[string]string, len(values))
+	for _, value := range values {
+		prefix, token, ok := strings.Cut(value, "=")
+		if !ok || prefix == "" || token == "" {
+			return nil, errors.New("invalid bearer token, expected 'prefix=token'")
+		}
+		tokens[prefix] = token
+	}
+	return tokens, nil
+}
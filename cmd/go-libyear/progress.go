@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	golibyear "github.com/nieomylnieja/go-libyear"
+)
+
+const (
+	progressBarWidth   = 30
+	progressPathLength = 50
+)
+
+// newProgressBar returns nil if w is not a terminal, progress is not reported then.
+func newProgressBar(w *os.File) *progressBar {
+	stat, err := w.Stat()
+	if err != nil || stat.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return &progressBar{w: w}
+}
+
+// progressBar renders the progress of processed modules in a single terminal line, which is redrawn on each update.
+// Data written to the bar, e.g. log records, is printed above it.
+type progressBar struct {
+	w    io.Writer
+	mu   sync.Mutex
+	line string
+}
+
+func (p *progressBar) Report(progress golibyear.Progress) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+	// Remove the bar once all modules are processed, so that it's not mixed with the output.
+	if progress.Done >= progress.Total {
+		p.line = ""
+		return
+	}
+	p.line = renderProgress(progress)
+	_, _ = io.WriteString(p.w, p.line)
+}
+
+func (p *progressBar) Write(data []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+	n, err := p.w.Write(data)
+	if err == nil && p.line != "" {
+		_, _ = io.WriteString(p.w, p.line)
+	}
+	return n, err
+}
+
+func (p *progressBar) clear() {
+	if p.line != "" {
+		_, _ = io.WriteString(p.w, "\r\033[K")
+	}
+}
+
+func renderProgress(progress golibyear.Progress) string {
+	filled := 0
+	if progress.Total > 0 {
+		filled = progressBarWidth * progress.Done / progress.Total
+	}
+	path := progress.Path
+	if len(path) > progressPathLength {
+		path = "..." + path[len(path)-progressPathLength+3:]
+	}
+	return fmt.Sprintf("[%s%s] %d/%d %s",
+		strings.Repeat("=", filled), strings.Repeat(" ", progressBarWidth-filled),
+		progress.Done, progress.Total, path)
+}
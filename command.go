@@ -2,8 +2,7 @@ package libyear
 
 import (
 	"context"
-	"log"
-	"os"
+	"log/slog"
 	pathlib "path"
 	"slices"
 	"sort"
@@ -21,13 +20,25 @@ import (
 type Option int
 
 const (
-	OptionShowReleases          Option = 1 << iota // 1
-	OptionShowVersions                             // 2
-	OptionSkipFresh                                // 4
-	OptionIncludeIndirect                          // 8
-	OptionUseGoList                                // 16
-	OptionFindLatestMajor                          // 32
-	OptionNoLibyearCompensation                    // 32
+	OptionShowReleases                Option = 1 << iota // 1
+	OptionShowVersions                                   // 2
+	OptionSkipFresh                                      // 4
+	OptionIncludeIndirect                                // 8
+	OptionUseGoList                                      // 16
+	OptionFindLatestMajor                                // 32
+	OptionNoLibyearCompensation                          // 32
+	OptionShowRetracted                                  // 128
+	OptionShowDeprecated                                 // 256
+	OptionFailOnRetractedOrDeprecated                    // 512
+	OptionShowToolchain                                  // 1024
+	OptionShowVulnerabilities                            // 2048
+	OptionShowActivity                                   // 4096
+	OptionShowUsage                                      // 8192
+	OptionSkipNonProduction                              // 16384
+	OptionSeparateTools                                  // 32768
+	OptionExcludeTools                                   // 65536
+	OptionShowMigrations                                 // 131072
+	OptionVerifyChecksums                                // 262144
 )
 
 //go:generate mockgen -destination internal/mocks/command.go -package mocks -typed . ModulesRepo,VersionsGetter
@@ -43,6 +54,17 @@ type VersionsGetter interface {
 	GetVersions(path string) ([]*semver.Version, error)
 }
 
+// cacheStatsSaver is implemented by [ModulesRepo] which caches the modules' information.
+type cacheStatsSaver interface {
+	SaveCacheStats() error
+}
+
+// retractedExcluder is implemented by [ModulesRepo] which never reports retracted versions,
+// neither as latest nor in the versions list.
+type retractedExcluder interface {
+	ExcludesRetracted() bool
+}
+
 type Command struct {
 	source           Source
 	output           Output
@@ -51,6 +73,28 @@ type Command struct {
 	opts             Option
 	vcs              *VCSRegistry
 	ageLimit         time.Time
+	goReleases       GoReleasesGetter
+	vulnDB           VulnerabilitiesGetter
+	activityReleases int
+	inactivityPeriod time.Duration
+	aggregation      Aggregation
+	weighting        Weighting
+	weights          map[string]float64
+	packages         PackageUsageLister
+	// prereleases is the default prerelease policy, overridden per module by prereleaseOverrides.
+	prereleases         PrereleasePolicy
+	prereleaseOverrides map[string]PrereleasePolicy
+	// trace is only set in explain mode.
+	trace *tracer
+	// excludes are read from the analyzed go.mod file.
+	excludes internal.Excludes
+	// checksums is only set with OptionVerifyChecksums.
+	checksums *internal.ModFileVerifier
+	// concurrency is the number of modules processed concurrently.
+	concurrency int
+	// logger is optional, if nil, [slog.Default] is used.
+	logger   *slog.Logger
+	progress ProgressFunc
 }
 
 func (c Command) Run(ctx context.Context) error {
@@ -59,43 +103,169 @@ func (c Command) Run(ctx context.Context) error {
 		return err
 	}
 
-	mainModule, modules, err := internal.ReadGoMod(data)
+	goMod, err := internal.ReadGoMod(data)
 	if err != nil {
 		return err
 	}
+	mainModule, modules := goMod.Main, goMod.Modules
+	c.excludes = goMod.Excludes
 	mainModule.Time = time.Now()
+	toolsSeparated := c.optionIsSet(OptionSeparateTools) || c.optionIsSet(OptionExcludeTools)
 	if !c.optionIsSet(OptionIncludeIndirect) {
-		// Filter out indirect.
-		modules = slices.DeleteFunc(modules, func(module *internal.Module) bool { return module.Indirect })
+		// Filter out indirect, modules providing tools are required indirectly,
+		// they are only kept if tools are reported separately or excluded.
+		modules = slices.DeleteFunc(modules, func(module *internal.Module) bool {
+			return module.Indirect && !(module.Tool && toolsSeparated)
+		})
+	}
+	// Tools' dependencies are only known if the packages are analyzed.
+	if c.weighting == WeightingPackages ||
+		c.optionIsSet(OptionShowUsage) ||
+		c.optionIsSet(OptionSkipNonProduction) ||
+		(toolsSeparated && c.packages != nil) {
+		if err = c.loadPackageUsage(modules); err != nil {
+			return err
+		}
+		for _, module := range modules {
+			module.Tool = module.Usage.Tool
+		}
+	}
+	if c.optionIsSet(OptionExcludeTools) {
+		// Filter out tool modules.
+		modules = slices.DeleteFunc(modules, func(module *internal.Module) bool { return module.Tool })
+	}
+	if c.optionIsSet(OptionSkipNonProduction) {
+		// Filter out modules which are only used by tests, tools or not imported at all.
+		modules = slices.DeleteFunc(modules, func(module *internal.Module) bool { return !module.Usage.IsProduction() })
 	}
 
+	// Local replacements are not published anywhere, there's nothing to compare them against.
+	published := slices.DeleteFunc(slices.Clone(modules), func(module *internal.Module) bool { return module.IsLocal() })
+	progress := newProgressReporter(c.progress, len(published))
 	group, _ := c.newErrGroup(ctx)
-	for _, module := range modules {
+	for _, module := range published {
 		module := module
-		group.Go(func() error { return c.runForModule(module) })
+		group.Go(func() error {
+			c.log().Debug("processing module", "path", module.Path, "version", "v"+module.Version.String())
+			err := c.runForModule(module)
+			progress.moduleDone(module.Path)
+			return err
+		})
 	}
 	if err = group.Wait(); err != nil {
 		return err
 	}
+	if saver, ok := c.repo.(cacheStatsSaver); ok {
+		if err = saver.SaveCacheStats(); err != nil {
+			return errors.Wrap(err, "failed to save cache stats")
+		}
+	}
 	// Remove skipped modules.
 	if c.optionIsSet(OptionSkipFresh) {
-		modules = slices.DeleteFunc(modules, func(module *internal.Module) bool { return module.Skipped })
+		// Checksum mismatches are never hidden.
+		modules = slices.DeleteFunc(modules, func(module *internal.Module) bool {
+			return module.Skipped && module.ChecksumMismatch == nil
+		})
+	}
+
+	var toolchain *ToolchainSummary
+	if c.optionIsSet(OptionShowToolchain) {
+		if toolchain, err = c.runForToolchain(goMod); err != nil {
+			return err
+		}
+	}
+
+	// Tools are moved to a separate section below, verification covers all modules.
+	verified := slices.Clone(modules)
+
+	// Tools are reported in a separate section, with their own totals.
+	var tools *ToolsSummary
+	if c.optionIsSet(OptionSeparateTools) {
+		tools = &ToolsSummary{
+			Main: &internal.Module{Path: toolsSectionName, Time: mainModule.Time},
+		}
+		for _, module := range modules {
+			if module.Tool {
+				tools.Modules = append(tools.Modules, module)
+			}
+		}
+		modules = slices.DeleteFunc(modules, func(module *internal.Module) bool { return module.Tool })
+		c.aggregate(tools.Main, tools.Modules)
 	}
 
 	// Aggregate results for main module.
+	c.aggregate(mainModule, modules)
+
+	// Prepare and send summary.
+	if err = c.output.Send(Summary{
+		Modules:     modules,
+		Main:        mainModule,
+		Toolchain:   toolchain,
+		Tools:       tools,
+		releases:    c.optionIsSet(OptionShowReleases),
+		versions:    c.optionIsSet(OptionShowVersions),
+		retracted:   c.optionIsSet(OptionShowRetracted),
+		deprecated:  c.optionIsSet(OptionShowDeprecated),
+		vulns:       c.optionIsSet(OptionShowVulnerabilities),
+		activity:    c.optionIsSet(OptionShowActivity),
+		usage:       c.optionIsSet(OptionShowUsage),
+		migrations:  c.optionIsSet(OptionShowMigrations),
+		checksums:   c.optionIsSet(OptionVerifyChecksums),
+		aggregation: c.describeAggregation(),
+	}); err != nil {
+		return err
+	}
+	if c.optionIsSet(OptionVerifyChecksums) {
+		if err = checkChecksumMismatches(verified); err != nil {
+			return err
+		}
+	}
+	if c.optionIsSet(OptionFailOnRetractedOrDeprecated) {
+		return checkRetractedOrDeprecated(modules)
+	}
+	return nil
+}
+
+// checkRetractedOrDeprecated returns an error if any of the modules
+// is using a retracted version or was deprecated.
+func checkRetractedOrDeprecated(modules []*internal.Module) error {
+	var retracted, deprecated []string
 	for _, module := range modules {
-		mainModule.Libyear += module.Libyear
-		mainModule.ReleasesDiff += module.ReleasesDiff
-		mainModule.VersionsDiff = mainModule.VersionsDiff.Add(module.VersionsDiff)
+		if module.IsUpstream() {
+			continue
+		}
+		if module.Retracted {
+			retracted = append(retracted, module.Path+"@v"+module.Version.String())
+		}
+		if module.Deprecated != "" {
+			deprecated = append(deprecated, module.Path)
+		}
 	}
+	var msgs []string
+	if len(retracted) > 0 {
+		msgs = append(msgs, "retracted versions: "+strings.Join(retracted, ", "))
+	}
+	if len(deprecated) > 0 {
+		msgs = append(msgs, "deprecated modules: "+strings.Join(deprecated, ", "))
+	}
+	if len(msgs) > 0 {
+		return errors.Errorf("detected %s", strings.Join(msgs, "; "))
+	}
+	return nil
+}
 
-	// Prepare and send summary.
-	return c.output.Send(Summary{
-		Modules:  modules,
-		Main:     mainModule,
-		releases: c.optionIsSet(OptionShowReleases),
-		versions: c.optionIsSet(OptionShowVersions),
-	})
+// checkChecksumMismatches returns an error if go.mod file of any of the modules did not match its checksum.
+func checkChecksumMismatches(modules []*internal.Module) error {
+	var mismatched []string
+	for _, module := range modules {
+		if module.ChecksumMismatch != nil {
+			mismatched = append(mismatched, module.ChecksumMismatch.Error())
+		}
+	}
+	if len(mismatched) > 0 {
+		return errors.Errorf("detected %d go.mod checksum mismatches:\n%s", len(mismatched), strings.Join(mismatched, "\n"))
+	}
+	return nil
 }
 
 const secondsInYear = float64(365 * 24 * 60 * 60)
@@ -113,15 +283,32 @@ func (c Command) runForModule(module *internal.Module) error {
 		if err != nil {
 			return err
 		}
+		c.trace.printf("module is private, using VCS handler")
 	}
+	c.trace.printf("source: %s", describeRepo(repo))
 
 	// Since we're parsing the go.mod file directly, we might need to fetch the Module.Time.
+	// Pseudo-versions have the commit time embedded, no need to ask for it.
+	if module.Time.IsZero() && module.IsPseudoVersion() {
+		pseudoTime, err := internal.PseudoVersionTime(module.Version)
+		if err != nil {
+			return err
+		}
+		module.Time = pseudoTime
+		c.trace.printf("current version release date taken from pseudo-version: %s", formatTime(module.Time))
+	}
 	if module.Time.IsZero() {
 		fetchedModule, err := repo.GetInfo(module.Path, module.Version)
 		if err != nil {
 			return err
 		}
 		module.Time = fetchedModule.Time
+		c.trace.printf("current version release date: %s", formatTime(module.Time))
+	}
+	if c.optionIsSet(OptionShowVulnerabilities) {
+		if err := c.checkVulnerabilities(repo, module); err != nil {
+			return err
+		}
 	}
 
 	// Fetch latest.
@@ -129,8 +316,23 @@ func (c Command) runForModule(module *internal.Module) error {
 	if err != nil {
 		return err
 	}
+	if c.optionIsSet(OptionShowActivity) {
+		if module.Activity, err = c.calculateActivity(repo, latest); err != nil {
+			return err
+		}
+	}
+	if c.optionIsSet(OptionShowMigrations) && module.IsIncompatible() {
+		if module.Migration, err = c.findMigration(repo, module, latest); err != nil {
+			return err
+		}
+		if module.Migration != nil {
+			module.MigrationLibyear = calculateLibyear(module.Time, module.Migration.Time)
+		}
+	}
 	// It returns -1 (smaller), 0 (larger), or 1 (greater) when compared.
+	c.trace.printf("latest: %s@v%s, released %s", latest.Path, latest.Version, formatTime(latest.Time))
 	if module.Version.Compare(latest.Version) != -1 {
+		c.trace.printf("current version v%s is not older than latest, libyear is 0", module.Version)
 		module.Latest = module
 		module.Time = latest.Time
 		return nil
@@ -145,26 +347,41 @@ func (c Command) runForModule(module *internal.Module) error {
 		if err != nil {
 			return err
 		}
+		c.trace.printf("first version of latest major: %s@v%s, released %s",
+			latest.Path, first.Version, formatTime(first.Time))
 		if module.Time.After(first.Time) {
-			log.Printf("INFO: current module version %s is newer than latest version %s; "+
-				"libyear will be calculated from the first version of latest major (%s) to the latest version (%s); "+
-				"if you wish to disable this behavior, use --allow-negative-libyear flag",
-				module.Version, latest.Version, first.Version, module.Version)
+			c.trace.printf("compensation applied: current version was released after the first version of latest major")
+			c.log().Info("current module version is newer than latest version, "+
+				"libyear will be calculated from the first version of latest major to the latest version; "+
+				"if you wish to disable this behavior, use --no-libyear-compensation flag",
+				"path", module.Path,
+				"version", "v"+module.Version.String(),
+				"latest", "v"+latest.Version.String(),
+				"first", "v"+first.Version.String())
 			currentTime = first.Time
+		} else {
+			c.trace.printf("compensation not applied: current version was released before the first version of latest major")
 		}
 	}
 	// The following calculations are based on https://ericbouwers.github.io/papers/icse15.pdf.
 	module.Libyear = calculateLibyear(currentTime, latest.Time)
+	c.trace.printf("libyear = (%s - %s) / 365 days = %.2f",
+		formatTime(latest.Time), formatTime(currentTime), module.Libyear)
 	if c.optionIsSet(OptionShowReleases) {
 		versions, err := c.getAllVersions(repo, latest)
 		if err == errNoVersions {
-			log.Printf("WARN: module '%s' does not have any versions", module.Path)
+			c.log().Warn("module does not have any versions", "path", module.Path)
 			return nil
 		}
+		versions = c.filterPrereleases(module.Path, module.Version, versions, module.Version, latest.Version)
 		module.ReleasesDiff = calculateReleases(module, latest, versions)
+		c.trace.versions("versions considered for releases", versions)
+		c.trace.printf("releases between v%s and v%s = %d", module.Version, latest.Version, module.ReleasesDiff)
 	}
 	if c.optionIsSet(OptionShowVersions) {
 		module.VersionsDiff = calculateVersions(module, latest)
+		c.trace.printf("version number delta between v%s and v%s = %s",
+			module.Version, latest.Version, module.VersionsDiff)
 	}
 
 	module.Skipped = false
@@ -173,6 +390,13 @@ func (c Command) runForModule(module *internal.Module) error {
 
 var errNoVersions = errors.New("no versions found")
 
+// filterExcluded removes the versions excluded by the analyzed go.mod file.
+func (c Command) filterExcluded(path string, versions []*semver.Version) []*semver.Version {
+	filtered := c.excludes.Filter(path, versions)
+	c.trace.filtered("excluded by go.mod", versions, filtered)
+	return filtered
+}
+
 func (c Command) getAllVersions(repo ModulesRepo, latest *internal.Module) ([]*semver.Version, error) {
 	allVersions := make([]*semver.Version, 0)
 	for _, path := range latest.AllPaths {
@@ -180,7 +404,7 @@ func (c Command) getAllVersions(repo ModulesRepo, latest *internal.Module) ([]*s
 		if err != nil {
 			return nil, err
 		}
-		allVersions = append(allVersions, versions...)
+		allVersions = append(allVersions, c.filterExcluded(path, versions)...)
 	}
 	sort.Sort(semver.Collection(allVersions))
 	return allVersions, nil
@@ -191,6 +415,7 @@ func (c Command) getVersionsForPath(repo ModulesRepo, path string, isPrerelease
 	if err != nil {
 		return nil, err
 	}
+	c.trace.versions("versions of "+path+" listed by "+describeRepo(repo), versions)
 	if len(versions) > 0 {
 		return versions, nil
 	}
@@ -209,6 +434,7 @@ func (c Command) getVersionsForPath(repo ModulesRepo, path string, isPrerelease
 	if err != nil {
 		return nil, err
 	}
+	c.trace.versions("versions of "+path+" listed by fallback "+describeRepo(fallback), versions)
 	// Check again.
 	if len(versions) == 0 {
 		return nil, errNoVersions
@@ -227,8 +453,18 @@ func (c Command) getLatestInfo(current *internal.Module, repo ModulesRepo) (*int
 			lts *internal.Module
 			err error
 		)
+		c.trace.printf("probing path %s", path)
 		if c.ageLimit.IsZero() {
 			lts, err = repo.GetLatestInfo(path)
+			if err == nil {
+				c.trace.printf("latest version of %s reported by %s: v%s", path, describeRepo(repo), lts.Version)
+				// Current version is only relevant for its own path.
+				var currentVersion *semver.Version
+				if latest == nil {
+					currentVersion = current.Version
+				}
+				lts, err = c.applyPrereleasePolicy(repo, path, currentVersion, lts)
+			}
 		} else {
 			// If this is the first iteration, optimize findLatestBefore by passing it the current version module.
 			if latest == nil {
@@ -239,10 +475,33 @@ func (c Command) getLatestInfo(current *internal.Module, repo ModulesRepo) (*int
 		}
 		if err != nil {
 			if strings.Contains(err.Error(), "no matching versions") {
+				c.trace.printf("no matching versions found in %s", path)
 				break
 			}
 			return nil, err
 		}
+		if c.excludes.IsExcluded(path, lts.Version) {
+			c.trace.printf("v%s is excluded by go.mod, looking for the greatest allowed version", lts.Version)
+			lts, err = c.findGreatestAllowed(repo, path, lts, func(v *semver.Version) bool {
+				return c.excludes.IsExcluded(path, v)
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		if path == current.Path && current.IsIncompatible() && !lts.IsIncompatible() {
+			c.trace.printf("current version is +incompatible, looking for greater +incompatible versions")
+			if lts, err = c.findLatestIncompatible(repo, current, lts); err != nil {
+				return nil, err
+			}
+		}
+		if c.isModFileRequired(repo) {
+			lts, err = c.checkModFile(repo, path, current, lts)
+			if err != nil {
+				return nil, err
+			}
+		}
+		c.trace.printf("latest version of %s: v%s, released %s", path, lts.Version, formatTime(lts.Time))
 		// In case for whatever reason we start endlessly looping here, break it.
 		if latest != nil && latest.Version.Compare(lts.Version) == 0 {
 			return latest, nil
@@ -269,6 +528,101 @@ func (c Command) getLatestInfo(current *internal.Module, repo ModulesRepo) (*int
 	return latest, nil
 }
 
+// isModFileRequired reports whether the go.mod file of the latest version has to be read.
+// Retracted versions are never selected as latest, the file is thus read unless the repo excludes them itself
+// or the retraction and deprecation status are displayed.
+func (c Command) isModFileRequired(repo ModulesRepo) bool {
+	if c.optionIsSet(OptionShowRetracted) || c.optionIsSet(OptionShowDeprecated) {
+		return true
+	}
+	excluder, ok := repo.(retractedExcluder)
+	return !ok || !excluder.ExcludesRetracted()
+}
+
+// checkModFile reads the go.mod file of the latest version in the given path.
+// If the current module lives in the same path, its retraction and deprecation status is updated,
+// as both are declared in the latest version's go.mod.
+// If the latest version was itself retracted, the greatest non-retracted version is returned instead.
+// If the go.mod file does not exist, there's nothing retracted nor deprecated.
+func (c Command) checkModFile(
+	repo ModulesRepo,
+	path string,
+	current, latest *internal.Module,
+) (*internal.Module, error) {
+	data, err := repo.GetModFile(path, latest.Version)
+	if err != nil {
+		if internal.IsNotFound(err) {
+			c.trace.printf("go.mod file of %s@v%s not found", path, latest.Version)
+			return latest, nil
+		}
+		return nil, err
+	}
+	if c.checksums != nil {
+		var mismatch *internal.ChecksumMismatchError
+		switch err = c.checksums.Verify(path, latest.Version, data); {
+		case errors.As(err, &mismatch):
+			// The go.mod file can't be trusted, retraction and deprecation status remains unknown.
+			c.trace.printf("%v", mismatch)
+			if current.ChecksumMismatch == nil {
+				current.ChecksumMismatch = mismatch
+			}
+			return latest, nil
+		case err != nil:
+			return nil, errors.Wrapf(err, "failed to verify go.mod file of %s@v%s", path, latest.Version)
+		}
+	}
+	info, err := internal.ReadModFileInfo(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse go.mod file of %s@v%s", path, latest.Version)
+	}
+	if path == current.Path {
+		current.Retracted = c.optionIsSet(OptionShowRetracted) && info.IsRetracted(current.Version)
+		if c.optionIsSet(OptionShowDeprecated) {
+			current.Deprecated = info.Deprecated
+		}
+	}
+	if !info.IsRetracted(latest.Version) {
+		return latest, nil
+	}
+	c.trace.printf("v%s is retracted, looking for the greatest non-retracted version", latest.Version)
+	return c.findGreatestAllowed(repo, path, latest, func(v *semver.Version) bool {
+		return info.IsRetracted(v) || c.excludes.IsExcluded(path, v)
+	})
+}
+
+// findGreatestAllowed returns the greatest version in the given path, which is not greater than latest
+// and which is not disallowed. Prerelease versions are only considered if latest is a prerelease.
+// If every version was disallowed, there's nothing better to choose from and latest is returned.
+func (c Command) findGreatestAllowed(
+	repo ModulesRepo,
+	path string,
+	latest *internal.Module,
+	disallowed func(v *semver.Version) bool,
+) (*internal.Module, error) {
+	isPrerelease := latest.Version.Prerelease() != ""
+	versions, err := c.getVersionsForPath(repo, path, isPrerelease)
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(sort.Reverse(semver.Collection(versions)))
+	for _, version := range versions {
+		if version.GreaterThan(latest.Version) ||
+			disallowed(version) ||
+			(!isPrerelease && version.Prerelease() != "") {
+			continue
+		}
+		candidate, err := repo.GetInfo(path, version)
+		if err != nil {
+			return nil, err
+		}
+		if !c.ageLimit.IsZero() && candidate.Time.After(c.ageLimit) {
+			continue
+		}
+		return candidate, nil
+	}
+	return latest, nil
+}
+
 // findFirstModule finds the first module in the given path.
 // If the path has /v2 or higher suffix it will find the first module in this version.
 func (c Command) findFirstModule(repo ModulesRepo, path string) (*internal.Module, error) {
@@ -276,9 +630,14 @@ func (c Command) findFirstModule(repo ModulesRepo, path string) (*internal.Modul
 	if err != nil {
 		return nil, err
 	}
+	c.trace.versions("versions of "+path+" listed by "+describeRepo(repo), versions)
 	if len(versions) == 0 {
 		return nil, errors.Errorf("no versions found for path %s, expected at least one", path)
 	}
+	// If there are only prereleases, the first one is used regardless of the prerelease policy.
+	if stable := c.filterPrereleases(path, nil, versions); len(stable) > 0 {
+		versions = stable
+	}
 	sort.Sort(semver.Collection(versions))
 	return repo.GetInfo(path, versions[0])
 }
@@ -303,6 +662,16 @@ func calculateLibyear(moduleTime, latestTime time.Time) float64 {
 }
 
 func calculateReleases(module, latest *internal.Module, versions []*semver.Version) int {
+	// Pseudo-versions are usually not listed, count the versions released after it instead.
+	if module.IsPseudoVersion() && !slices.ContainsFunc(versions, module.Version.Equal) {
+		releases := 0
+		for _, v := range versions {
+			if v.GreaterThan(module.Version) && !v.GreaterThan(latest.Version) {
+				releases++
+			}
+		}
+		return releases
+	}
 	currentIndex := slices.IndexFunc(versions, func(v *semver.Version) bool { return module.Version.Equal(v) })
 	latestIndex := slices.IndexFunc(versions, func(v *semver.Version) bool { return latest.Version.Equal(v) })
 	// Example:
@@ -344,16 +713,27 @@ func calculateVersions(module, latest *internal.Module) internal.VersionsDiff {
 	}
 }
 
+// DefaultConcurrency is the default number of modules processed concurrently.
+const DefaultConcurrency = 4
+
 func (c Command) newErrGroup(ctx context.Context) (*errgroup.Group, context.Context) {
 	group, ctx := errgroup.WithContext(ctx)
-	maxProcs, _ := strconv.Atoi(os.Getenv("GOMAXPROCS"))
-	if maxProcs == 0 {
-		maxProcs = 4
+	concurrency := c.concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
 	}
-	group.SetLimit(maxProcs)
+	group.SetLimit(concurrency)
 	return group, ctx
 }
 
+// log returns the command's logger, or [slog.Default] if it's not set.
+func (c Command) log() *slog.Logger {
+	if c.logger != nil {
+		return c.logger
+	}
+	return slog.Default()
+}
+
 func (c Command) optionIsSet(option Option) bool {
 	return c.opts&option != 0
 }
@@ -375,12 +755,20 @@ func (c Command) findLatestBefore(repo ModulesRepo, path string, current *intern
 	if err != nil {
 		return nil, err
 	}
+	versions = c.filterExcluded(path, versions)
+	if current != nil {
+		versions = c.filterPrereleases(path, current.Version, versions, current.Version)
+	} else {
+		versions = c.filterPrereleases(path, nil, versions)
+	}
 	sort.Sort(semver.Collection(versions))
 	// Optimize the search if current was provided.
 	if current != nil {
 		currentIndex := slices.IndexFunc(versions, func(v *semver.Version) bool { return current.Version.Equal(v) })
 		versions = versions[currentIndex+1:]
 	}
+	c.trace.versions("searching for the latest version of "+path+" published before "+
+		c.ageLimit.Format(time.DateOnly)+" among", versions)
 	start, end := 0, (len(versions) - 1)
 	latest := current
 	for start <= end {
@@ -1,7 +1,14 @@
 package libyear
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
 	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"strconv"
 	"testing"
 	"time"
@@ -119,6 +126,18 @@ func TestCommand_calculateReleases(t *testing.T) {
 			},
 			Expected: 0,
 		},
+		// Pseudo-versions are not listed.
+		{
+			CurrentVersion: "v0.1.1-0.20230101120000-abcdef123456",
+			LatestVersion:  "v0.3.0",
+			Versions: []string{
+				"v0.1.0",
+				"v0.2.0",
+				"v0.3.0",
+				"v0.4.0-rc1",
+			},
+			Expected: 2,
+		},
 	}
 	for i, test := range tests {
 		t.Run(strconv.Itoa(i), func(t *testing.T) {
@@ -341,6 +360,7 @@ func TestCommand_GetLatestInfo(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			modulesRepo := mocks.NewMockModulesRepo(ctrl)
+			expectModFiles(modulesRepo)
 			for _, call := range test.Calls {
 				modulesRepo.EXPECT().
 					GetLatestInfo(call.Input).
@@ -521,6 +541,7 @@ func TestCommand_HandleFixVersionsWhenNewMajorIsAvailable(t *testing.T) {
 		Time:    mustParseTime(t, "2023-01-08"),
 	}
 	modulesRepo := mocks.NewMockModulesRepo(ctrl)
+	expectModFiles(modulesRepo)
 	modulesRepo.EXPECT().
 		GetLatestInfo("github.com/go-playground/validator").
 		Times(1).
@@ -553,10 +574,12 @@ func TestCommand_HandleFixVersionsWhenNewMajorIsAvailable(t *testing.T) {
 			Version: semver.MustParse("v10.0.0"),
 			Time:    mustParseTime(t, "2023-01-01"),
 		}, nil)
+	var logs bytes.Buffer
 	cmd := Command{
-		repo: modulesRepo,
-		opts: OptionFindLatestMajor,
-		vcs:  &VCSRegistry{},
+		repo:   modulesRepo,
+		opts:   OptionFindLatestMajor,
+		vcs:    &VCSRegistry{},
+		logger: slog.New(slog.NewJSONHandler(&logs, nil)),
 	}
 
 	module := currentLatest
@@ -564,6 +587,11 @@ func TestCommand_HandleFixVersionsWhenNewMajorIsAvailable(t *testing.T) {
 
 	require.NoError(t, err)
 	assert.InEpsilon(t, 9./365., module.Libyear, 0.1)
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &record))
+	assert.Equal(t, "INFO", record["level"])
+	assert.Equal(t, "v10.0.0", record["first"])
+	assert.Equal(t, "v10.1.0", record["latest"])
 }
 
 func TestCommand_HandleFixVersionsWhenNewMajorIsAvailable_NoCompensate(t *testing.T) {
@@ -574,6 +602,7 @@ func TestCommand_HandleFixVersionsWhenNewMajorIsAvailable_NoCompensate(t *testin
 		Time:    mustParseTime(t, "2023-01-14"),
 	}
 	modulesRepo := mocks.NewMockModulesRepo(ctrl)
+	expectModFiles(modulesRepo)
 	modulesRepo.EXPECT().
 		GetLatestInfo("github.com/go-playground/validator").
 		Times(1).
@@ -603,6 +632,638 @@ func TestCommand_HandleFixVersionsWhenNewMajorIsAvailable_NoCompensate(t *testin
 	assert.Zero(t, module.Libyear)
 }
 
+func TestCommand_PseudoVersion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	module := &internal.Module{
+		Path:    "github.com/xrash/smetrics",
+		Version: semver.MustParse("v0.0.0-20230101120000-abcdef123456"),
+	}
+	modulesRepo := mocks.NewMockModulesRepo(ctrl)
+	expectModFiles(modulesRepo)
+	modulesRepo.EXPECT().
+		GetInfo(gomock.Any(), gomock.Any()).
+		Times(0)
+	modulesRepo.EXPECT().
+		GetLatestInfo("github.com/xrash/smetrics").
+		Times(1).
+		Return(&internal.Module{
+			Path:    "github.com/xrash/smetrics",
+			Version: semver.MustParse("v0.1.0"),
+			Time:    mustParseTime(t, "2024-01-01"),
+		}, nil)
+	cmd := Command{
+		repo: modulesRepo,
+		vcs:  &VCSRegistry{},
+	}
+
+	err := cmd.runForModule(module)
+
+	require.NoError(t, err)
+	assert.True(t, module.IsPseudoVersion())
+	assert.Equal(t, time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC), module.Time)
+	assert.InEpsilon(t, 1., module.Libyear, 0.01)
+}
+
+func TestCommand_RetractedAndDeprecated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	path := "github.com/nieomylnieja/go-libyear"
+	current := &internal.Module{
+		Path:    path,
+		Version: semver.MustParse("v1.0.0"),
+	}
+	modulesRepo := mocks.NewMockModulesRepo(ctrl)
+	modulesRepo.EXPECT().
+		GetLatestInfo(path).
+		Times(1).
+		Return(&internal.Module{Path: path, Version: semver.MustParse("v1.3.0")}, nil)
+	modulesRepo.EXPECT().
+		GetModFile(path, semver.MustParse("v1.3.0")).
+		Times(1).
+		Return([]byte(`// Deprecated: use github.com/nieomylnieja/go-libyear/v2 instead.
+module github.com/nieomylnieja/go-libyear
+
+retract (
+	v1.0.0 // Broken.
+	[v1.2.0, v1.3.0] // Published by accident.
+)
+`), nil)
+	modulesRepo.EXPECT().
+		GetVersions(path).
+		Times(1).
+		Return([]*semver.Version{
+			semver.MustParse("v1.0.0"),
+			semver.MustParse("v1.1.0"),
+			semver.MustParse("v1.2.0"),
+			semver.MustParse("v1.3.0"),
+		}, nil)
+	modulesRepo.EXPECT().
+		GetInfo(path, semver.MustParse("v1.1.0")).
+		Times(1).
+		Return(&internal.Module{Path: path, Version: semver.MustParse("v1.1.0")}, nil)
+	cmd := Command{opts: OptionShowRetracted | OptionShowDeprecated}
+
+	latest, err := cmd.getLatestInfo(current, modulesRepo)
+
+	require.NoError(t, err)
+	assert.Equal(t, semver.MustParse("v1.1.0"), latest.Version)
+	assert.True(t, current.Retracted)
+	assert.Equal(t, "use github.com/nieomylnieja/go-libyear/v2 instead.", current.Deprecated)
+	assert.EqualError(t, checkRetractedOrDeprecated([]*internal.Module{current}),
+		"detected retracted versions: github.com/nieomylnieja/go-libyear@v1.0.0; "+
+			"deprecated modules: github.com/nieomylnieja/go-libyear")
+}
+
+func TestCommand_RetractedLatestIsAlwaysExcluded(t *testing.T) {
+	path := "github.com/nieomylnieja/go-libyear"
+	for name, opts := range map[string]Option{
+		"no options":        0,
+		"deprecated column": OptionShowDeprecated,
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			current := &internal.Module{Path: path, Version: semver.MustParse("v1.0.0")}
+			modulesRepo := mocks.NewMockModulesRepo(ctrl)
+			modulesRepo.EXPECT().
+				GetLatestInfo(path).
+				Times(1).
+				Return(&internal.Module{Path: path, Version: semver.MustParse("v1.2.0")}, nil)
+			modulesRepo.EXPECT().
+				GetModFile(path, semver.MustParse("v1.2.0")).
+				Times(1).
+				Return([]byte("module github.com/nieomylnieja/go-libyear\n\nretract (\n\tv1.0.0\n\tv1.2.0\n)\n"), nil)
+			modulesRepo.EXPECT().
+				GetVersions(path).
+				Times(1).
+				Return([]*semver.Version{
+					semver.MustParse("v1.0.0"),
+					semver.MustParse("v1.1.0"),
+					semver.MustParse("v1.2.0"),
+				}, nil)
+			modulesRepo.EXPECT().
+				GetInfo(path, semver.MustParse("v1.1.0")).
+				Times(1).
+				Return(&internal.Module{Path: path, Version: semver.MustParse("v1.1.0")}, nil)
+			cmd := Command{opts: opts}
+
+			latest, err := cmd.getLatestInfo(current, modulesRepo)
+
+			require.NoError(t, err)
+			assert.Equal(t, semver.MustParse("v1.1.0"), latest.Version)
+			assert.False(t, current.Retracted, "retraction status is only reported with the column")
+		})
+	}
+}
+
+func TestCommand_RetractedLatestExcludedByRepo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	path := "github.com/nieomylnieja/go-libyear"
+	modulesRepo := mocks.NewMockModulesRepo(ctrl)
+	modulesRepo.EXPECT().
+		GetLatestInfo(path).
+		Times(1).
+		Return(&internal.Module{Path: path, Version: semver.MustParse("v1.1.0")}, nil)
+	modulesRepo.EXPECT().
+		GetModFile(gomock.Any(), gomock.Any()).
+		Times(0)
+	cmd := Command{}
+
+	latest, err := cmd.getLatestInfo(
+		&internal.Module{Path: path, Version: semver.MustParse("v1.0.0")},
+		retractedExcludingRepo{modulesRepo})
+
+	require.NoError(t, err)
+	assert.Equal(t, semver.MustParse("v1.1.0"), latest.Version)
+}
+
+type retractedExcludingRepo struct{ ModulesRepo }
+
+func (retractedExcludingRepo) ExcludesRetracted() bool { return true }
+
+func TestCommand_VerifyChecksums(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	path := "github.com/pkg/errors"
+	current := &internal.Module{Path: path, Version: semver.MustParse("v0.9.0")}
+	latestVersion := semver.MustParse("v0.9.1")
+	goSum, err := internal.ReadGoSum([]byte(
+		"github.com/pkg/errors v0.9.1/go.mod h1:bwawxfHBFNV+L2hUp1rHADufV3IMtnDRdf1r5NINEl0=\n"))
+	require.NoError(t, err)
+	modulesRepo := mocks.NewMockModulesRepo(ctrl)
+	modulesRepo.EXPECT().
+		GetLatestInfo(path).
+		Times(1).
+		Return(&internal.Module{Path: path, Version: latestVersion}, nil)
+	// Retraction of all versions would make the tampered file select a different latest version.
+	modulesRepo.EXPECT().
+		GetModFile(path, latestVersion).
+		Times(1).
+		Return([]byte("module github.com/pkg/errors\n\nretract [v0.0.0, v0.9.1]\n"), nil)
+	cmd := Command{
+		opts:      OptionShowRetracted | OptionVerifyChecksums,
+		checksums: &internal.ModFileVerifier{GoSum: goSum},
+	}
+
+	latest, err := cmd.getLatestInfo(current, modulesRepo)
+
+	require.NoError(t, err)
+	assert.Equal(t, latestVersion, latest.Version)
+	assert.False(t, current.Retracted, "untrusted go.mod file must not be used")
+	require.NotNil(t, current.ChecksumMismatch)
+	assert.Equal(t, "go.sum", current.ChecksumMismatch.Source)
+	assert.EqualError(t, checkChecksumMismatches([]*internal.Module{current}),
+		"detected 1 go.mod checksum mismatches:\n"+
+			"github.com/pkg/errors@v0.9.1: go.mod checksum mismatch, "+
+			"go.sum: h1:bwawxfHBFNV+L2hUp1rHADufV3IMtnDRdf1r5NINEl0=, "+
+			"downloaded: "+current.ChecksumMismatch.Actual)
+}
+
+func TestCommand_Excludes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	path := "github.com/nieomylnieja/go-libyear"
+	current := &internal.Module{
+		Path:    path,
+		Version: semver.MustParse("v1.0.0"),
+		Time:    mustParseTime(t, "2023-01-01"),
+	}
+	versions := []*semver.Version{
+		semver.MustParse("v1.0.0"),
+		semver.MustParse("v1.1.0"),
+		semver.MustParse("v1.2.0"),
+		semver.MustParse("v1.3.0"),
+	}
+	modulesRepo := mocks.NewMockModulesRepo(ctrl)
+	expectModFiles(modulesRepo)
+	modulesRepo.EXPECT().
+		GetLatestInfo(path).
+		Times(1).
+		Return(&internal.Module{Path: path, Version: semver.MustParse("v1.3.0")}, nil)
+	modulesRepo.EXPECT().
+		GetVersions(path).
+		Times(2).
+		Return(versions, nil)
+	modulesRepo.EXPECT().
+		GetInfo(path, semver.MustParse("v1.1.0")).
+		Times(1).
+		Return(&internal.Module{
+			Path:    path,
+			Version: semver.MustParse("v1.1.0"),
+			Time:    mustParseTime(t, "2023-01-10"),
+		}, nil)
+	cmd := Command{
+		repo: modulesRepo,
+		opts: OptionShowReleases,
+		vcs:  NewVCSRegistry(t.TempDir()),
+		excludes: internal.Excludes{path: {
+			semver.MustParse("v1.2.0"),
+			semver.MustParse("v1.3.0"),
+		}},
+	}
+
+	err := cmd.runForModule(current)
+
+	require.NoError(t, err)
+	assert.Equal(t, semver.MustParse("v1.1.0"), current.Latest.Version)
+	assert.Equal(t, 1, current.ReleasesDiff)
+}
+
+func TestCommand_Run_PackageUsage(t *testing.T) {
+	const goMod = `module github.com/nieomylnieja/test
+
+go 1.21
+
+require (
+	github.com/a/production v1.0.0
+	github.com/b/test v1.0.0
+	github.com/c/tool v1.0.0
+)
+`
+	ctrl := gomock.NewController(t)
+	modulesRepo := mocks.NewMockModulesRepo(ctrl)
+	expectModFiles(modulesRepo)
+	modulesRepo.EXPECT().
+		GetInfo("github.com/a/production", semver.MustParse("v1.0.0")).
+		Times(1).
+		Return(&internal.Module{Time: mustParseTime(t, "2023-01-01")}, nil)
+	modulesRepo.EXPECT().
+		GetLatestInfo("github.com/a/production").
+		Times(1).
+		Return(&internal.Module{Version: semver.MustParse("v1.1.0"), Time: mustParseTime(t, "2024-01-01")}, nil)
+	output := &summaryRecorder{}
+	cmd := Command{
+		source: bytesSource(goMod),
+		output: output,
+		repo:   modulesRepo,
+		vcs:    NewVCSRegistry(t.TempDir()),
+		opts:   OptionShowUsage | OptionSkipNonProduction,
+		packages: packageUsageListerFunc(func() (map[string]*internal.PackageUsage, error) {
+			return map[string]*internal.PackageUsage{
+				"github.com/a/production": {Packages: 2},
+				"github.com/b/test":       {Packages: 1, TestOnly: true},
+			}, nil
+		}),
+	}
+
+	err := cmd.Run(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, output.Summary.Modules, 1)
+	module := output.Summary.Modules[0]
+	assert.Equal(t, "github.com/a/production", module.Path)
+	assert.Equal(t, &internal.PackageUsage{Packages: 2}, module.Usage)
+	assert.InEpsilon(t, 1., output.Summary.Main.Libyear, 0.01)
+}
+
+func TestCommand_Run_SeparateTools(t *testing.T) {
+	const goMod = `module github.com/nieomylnieja/test
+
+go 1.24
+
+require (
+	github.com/a/production v1.0.0
+	github.com/b/tool v1.0.0 // indirect
+)
+
+tool github.com/b/tool/cmd/tool
+`
+	ctrl := gomock.NewController(t)
+	modulesRepo := mocks.NewMockModulesRepo(ctrl)
+	expectModFiles(modulesRepo)
+	for path, latest := range map[string]string{
+		"github.com/a/production": "2024-01-01",
+		"github.com/b/tool":       "2025-01-01",
+	} {
+		modulesRepo.EXPECT().
+			GetInfo(path, semver.MustParse("v1.0.0")).
+			Times(1).
+			Return(&internal.Module{Time: mustParseTime(t, "2023-01-01")}, nil)
+		modulesRepo.EXPECT().
+			GetLatestInfo(path).
+			Times(1).
+			Return(&internal.Module{Version: semver.MustParse("v1.1.0"), Time: mustParseTime(t, latest)}, nil)
+	}
+	output := &summaryRecorder{}
+	cmd := Command{
+		source: bytesSource(goMod),
+		output: output,
+		repo:   modulesRepo,
+		vcs:    NewVCSRegistry(t.TempDir()),
+		opts:   OptionSeparateTools,
+	}
+
+	err := cmd.Run(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, output.Summary.Modules, 1)
+	assert.Equal(t, "github.com/a/production", output.Summary.Modules[0].Path)
+	assert.InEpsilon(t, 1., output.Summary.Main.Libyear, 0.01)
+	require.NotNil(t, output.Summary.Tools)
+	require.Len(t, output.Summary.Tools.Modules, 1)
+	assert.Equal(t, "github.com/b/tool", output.Summary.Tools.Modules[0].Path)
+	assert.True(t, output.Summary.Tools.Modules[0].Tool)
+	assert.Equal(t, "tools", output.Summary.Tools.Main.Path)
+	assert.InEpsilon(t, 2., output.Summary.Tools.Main.Libyear, 0.01)
+}
+
+func TestCommand_Run_IndirectToolsAreFilteredOut(t *testing.T) {
+	const goMod = `module github.com/nieomylnieja/test
+
+go 1.24
+
+require (
+	github.com/a/production v1.0.0
+	github.com/b/tool v1.0.0 // indirect
+)
+
+tool github.com/b/tool/cmd/tool
+`
+	ctrl := gomock.NewController(t)
+	modulesRepo := mocks.NewMockModulesRepo(ctrl)
+	expectModFiles(modulesRepo)
+	modulesRepo.EXPECT().
+		GetInfo("github.com/a/production", semver.MustParse("v1.0.0")).
+		Times(1).
+		Return(&internal.Module{Time: mustParseTime(t, "2023-01-01")}, nil)
+	modulesRepo.EXPECT().
+		GetLatestInfo("github.com/a/production").
+		Times(1).
+		Return(&internal.Module{Version: semver.MustParse("v1.1.0"), Time: mustParseTime(t, "2024-01-01")}, nil)
+	output := &summaryRecorder{}
+	cmd := Command{
+		source: bytesSource(goMod),
+		output: output,
+		repo:   modulesRepo,
+		vcs:    NewVCSRegistry(t.TempDir()),
+	}
+
+	err := cmd.Run(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, output.Summary.Modules, 1)
+	assert.Equal(t, "github.com/a/production", output.Summary.Modules[0].Path)
+	assert.Nil(t, output.Summary.Tools)
+	assert.InEpsilon(t, 1., output.Summary.Main.Libyear, 0.01)
+}
+
+func TestCommand_Run_SeparateToolsAndVerifyChecksums(t *testing.T) {
+	const goMod = `module github.com/nieomylnieja/test
+
+go 1.24
+
+require (
+	github.com/a/production v1.0.0
+	github.com/b/tool v1.0.0 // indirect
+)
+
+tool github.com/b/tool/cmd/tool
+`
+	goSum, err := internal.ReadGoSum([]byte(
+		"github.com/b/tool v1.1.0/go.mod h1:bwawxfHBFNV+L2hUp1rHADufV3IMtnDRdf1r5NINEl0=\n"))
+	require.NoError(t, err)
+	ctrl := gomock.NewController(t)
+	modulesRepo := mocks.NewMockModulesRepo(ctrl)
+	for _, path := range []string{"github.com/a/production", "github.com/b/tool"} {
+		modulesRepo.EXPECT().
+			GetInfo(path, semver.MustParse("v1.0.0")).
+			Times(1).
+			Return(&internal.Module{Time: mustParseTime(t, "2023-01-01")}, nil)
+		modulesRepo.EXPECT().
+			GetLatestInfo(path).
+			Times(1).
+			Return(&internal.Module{Version: semver.MustParse("v1.1.0"), Time: mustParseTime(t, "2024-01-01")}, nil)
+		modulesRepo.EXPECT().
+			GetModFile(path, semver.MustParse("v1.1.0")).
+			Times(1).
+			Return([]byte("module "+path+"\n"), nil)
+	}
+	output := &summaryRecorder{}
+	cmd := Command{
+		source:    bytesSource(goMod),
+		output:    output,
+		repo:      modulesRepo,
+		vcs:       NewVCSRegistry(t.TempDir()),
+		opts:      OptionSeparateTools | OptionShowRetracted | OptionVerifyChecksums,
+		checksums: &internal.ModFileVerifier{GoSum: goSum},
+	}
+
+	err = cmd.Run(context.Background())
+
+	// Tools are verified, even though they're reported in a separate section.
+	require.ErrorContains(t, err, "detected 1 go.mod checksum mismatches:\ngithub.com/b/tool@v1.1.0")
+	require.Len(t, output.Summary.Modules, 1)
+	assert.Equal(t, "github.com/a/production", output.Summary.Modules[0].Path)
+	require.NotNil(t, output.Summary.Tools)
+	require.Len(t, output.Summary.Tools.Modules, 1)
+	assert.Equal(t, "github.com/b/tool", output.Summary.Tools.Modules[0].Path)
+}
+
+func TestCommand_Run_SeparateTools_PackageUsage(t *testing.T) {
+	const goMod = `module github.com/nieomylnieja/test
+
+go 1.24
+
+require (
+	github.com/a/production v1.0.0
+	github.com/b/tool v1.0.0 // indirect
+	github.com/c/tooldep v1.0.0 // indirect
+	github.com/d/shared v1.0.0 // indirect
+)
+
+tool github.com/b/tool/cmd/tool
+`
+	ctrl := gomock.NewController(t)
+	modulesRepo := mocks.NewMockModulesRepo(ctrl)
+	expectModFiles(modulesRepo)
+	for _, path := range []string{
+		"github.com/a/production",
+		"github.com/b/tool",
+		"github.com/c/tooldep",
+		"github.com/d/shared",
+	} {
+		modulesRepo.EXPECT().
+			GetInfo(path, semver.MustParse("v1.0.0")).
+			Times(1).
+			Return(&internal.Module{Time: mustParseTime(t, "2023-01-01")}, nil)
+		modulesRepo.EXPECT().
+			GetLatestInfo(path).
+			Times(1).
+			Return(&internal.Module{Version: semver.MustParse("v1.1.0"), Time: mustParseTime(t, "2024-01-01")}, nil)
+	}
+	output := &summaryRecorder{}
+	cmd := Command{
+		source: bytesSource(goMod),
+		output: output,
+		repo:   modulesRepo,
+		vcs:    NewVCSRegistry(t.TempDir()),
+		opts:   OptionSeparateTools | OptionIncludeIndirect,
+		packages: packageUsageListerFunc(func() (map[string]*internal.PackageUsage, error) {
+			return map[string]*internal.PackageUsage{
+				"github.com/a/production": {Packages: 2},
+				"github.com/b/tool":       {Packages: 1, Tool: true},
+				"github.com/c/tooldep":    {Packages: 1, Tool: true},
+				// Imported by both the tool and the project.
+				"github.com/d/shared": {Packages: 1},
+			}, nil
+		}),
+	}
+
+	err := cmd.Run(context.Background())
+
+	require.NoError(t, err)
+	paths := func(modules []*internal.Module) []string {
+		result := make([]string, 0, len(modules))
+		for _, module := range modules {
+			result = append(result, module.Path)
+		}
+		return result
+	}
+	assert.Equal(t, []string{"github.com/a/production", "github.com/d/shared"}, paths(output.Summary.Modules))
+	assert.InEpsilon(t, 2., output.Summary.Main.Libyear, 0.01)
+	require.NotNil(t, output.Summary.Tools)
+	assert.Equal(t, []string{"github.com/b/tool", "github.com/c/tooldep"}, paths(output.Summary.Tools.Modules))
+	assert.InEpsilon(t, 2., output.Summary.Tools.Main.Libyear, 0.01)
+}
+
+func TestCommand_Run_Progress(t *testing.T) {
+	const goMod = `module github.com/nieomylnieja/test
+
+go 1.21
+
+require (
+	github.com/a/a v1.0.0
+	github.com/b/b v1.0.0
+	github.com/c/local v1.0.0
+)
+
+replace github.com/c/local => ../local
+`
+	ctrl := gomock.NewController(t)
+	modulesRepo := mocks.NewMockModulesRepo(ctrl)
+	expectModFiles(modulesRepo)
+	for _, path := range []string{"github.com/a/a", "github.com/b/b"} {
+		modulesRepo.EXPECT().
+			GetInfo(path, semver.MustParse("v1.0.0")).
+			Times(1).
+			Return(&internal.Module{Time: mustParseTime(t, "2023-01-01")}, nil)
+		modulesRepo.EXPECT().
+			GetLatestInfo(path).
+			Times(1).
+			Return(&internal.Module{Version: semver.MustParse("v1.1.0"), Time: mustParseTime(t, "2024-01-01")}, nil)
+	}
+	var reported []Progress
+	cmd := Command{
+		source:   bytesSource(goMod),
+		output:   &summaryRecorder{},
+		repo:     modulesRepo,
+		vcs:      NewVCSRegistry(t.TempDir()),
+		progress: func(progress Progress) { reported = append(reported, progress) },
+	}
+
+	err := cmd.Run(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, reported, 2)
+	assert.ElementsMatch(t, []string{"github.com/a/a", "github.com/b/b"},
+		[]string{reported[0].Path, reported[1].Path})
+	assert.Equal(t, []int{1, 2}, []int{reported[0].Done, reported[1].Done})
+	assert.Equal(t, []int{2, 2}, []int{reported[0].Total, reported[1].Total})
+}
+
+type bytesSource []byte
+
+func (b bytesSource) Read() ([]byte, error) { return b, nil }
+
+func TestCommand_Run_SharedPathWithCache(t *testing.T) {
+	const goMod = `module github.com/nieomylnieja/test
+
+go 1.23
+
+require (
+	github.com/a/b v1.0.0
+	github.com/a/b/v2 v2.0.0
+)
+
+exclude github.com/a/b/v2 v2.2.0
+`
+	responses := map[string]string{
+		"/github.com/a/b/@v/v1.0.0.info":    `{"Version":"v1.0.0","Time":"2022-01-01T00:00:00Z"}`,
+		"/github.com/a/b/@latest":           `{"Version":"v1.1.0","Time":"2022-06-01T00:00:00Z"}`,
+		"/github.com/a/b/@v/list":           "v1.1.0\nv1.0.0\n",
+		"/github.com/a/b/v2/@v/v2.0.0.info": `{"Version":"v2.0.0","Time":"2023-01-01T00:00:00Z"}`,
+		"/github.com/a/b/v2/@latest":        `{"Version":"v2.2.0","Time":"2024-01-01T00:00:00Z"}`,
+		"/github.com/a/b/v2/@v/v2.1.0.info": `{"Version":"v2.1.0","Time":"2023-06-01T00:00:00Z"}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			http.Error(w, "no matching versions", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+	t.Setenv("GOPROXY", srv.URL)
+	cacheConfig := internal.CacheConfig{FilePath: filepath.Join(t.TempDir(), "cache")}
+	// Unsorted cached list is shared by both modules.
+	cache, err := internal.NewCache(cacheConfig)
+	require.NoError(t, err)
+	require.NoError(t, cache.SaveVersions("github.com/a/b/v2", []*semver.Version{
+		semver.MustParse("v2.0.0"),
+		semver.MustParse("v2.2.0"),
+		semver.MustParse("v2.1.0"),
+	}))
+	repo, err := internal.NewGoProxyClient(nil, true, cacheConfig)
+	require.NoError(t, err)
+
+	output := &summaryRecorder{}
+	cmd := Command{
+		source:      bytesSource(goMod),
+		output:      output,
+		repo:        repo,
+		vcs:         NewVCSRegistry(t.TempDir()),
+		opts:        OptionFindLatestMajor | OptionShowReleases | OptionShowVersions,
+		concurrency: 2,
+	}
+
+	// Versions of github.com/a/b/v2 are sorted for both modules, in order to find the greatest not excluded one.
+	err = cmd.Run(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, output.Summary.Modules, 2)
+	for _, module := range output.Summary.Modules {
+		require.NotNil(t, module.Latest)
+		assert.Equal(t, "2.1.0", module.Latest.Version.String())
+	}
+	// Cached list is not modified by the callers.
+	versions, err := repo.GetVersions("github.com/a/b/v2")
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	for i, expected := range []string{"2.0.0", "2.2.0", "2.1.0"} {
+		assert.Equal(t, expected, versions[i].String())
+	}
+}
+
+// expectModFiles allows reading go.mod files of any module version, none of them retracts any versions.
+func expectModFiles(modulesRepo *mocks.MockModulesRepo) {
+	modulesRepo.EXPECT().
+		GetModFile(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(path string, _ *semver.Version) ([]byte, error) {
+			return []byte("module " + path + "\n"), nil
+		})
+}
+
+type summaryRecorder struct{ Summary Summary }
+
+func (s *summaryRecorder) Send(summary Summary) error {
+	s.Summary = summary
+	return nil
+}
+
+type packageUsageListerFunc func() (map[string]*internal.PackageUsage, error)
+
+func (f packageUsageListerFunc) ListPackageUsage() (map[string]*internal.PackageUsage, error) {
+	return f()
+}
+
 func TestCommand_FindLatestBefore_CheckCurrentTime(t *testing.T) {
 	cmd := Command{ageLimit: mustParseTime(t, "2023-01-12")}
 
@@ -977,6 +1638,7 @@ func TestCommand_UseDefaultHandlerForPrivateRepoWithGoList(t *testing.T) {
 		Time:    mustParseTime(t, "2024-05-20"),
 	}
 	modulesRepo := mocks.NewMockModulesRepo(ctrl)
+	expectModFiles(modulesRepo)
 	modulesRepo.EXPECT().
 		GetLatestInfo("github.com/nieomylnieja/go-libyear").
 		Times(1).
@@ -0,0 +1,135 @@
+package libyear
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+
+	"github.com/nieomylnieja/go-libyear/internal"
+)
+
+// Explain calculates the metrics of a single module required by the go.mod file
+// and writes the trace of how they were derived to w.
+// The trace includes the queried sources, probed paths, considered and filtered versions
+// and the inputs of each calculation.
+func (c Command) Explain(ctx context.Context, modulePath string, w io.Writer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	goModFile, err := c.source.Read()
+	if err != nil {
+		return err
+	}
+	goMod, err := internal.ReadGoMod(goModFile)
+	if err != nil {
+		return err
+	}
+	c.excludes = goMod.Excludes
+	c.trace = &tracer{w: w}
+
+	index := slices.IndexFunc(goMod.Modules, func(m *internal.Module) bool { return m.Path == modulePath })
+	if index == -1 {
+		return errors.Errorf("module %s is not required by %s", modulePath, goMod.Main.Path)
+	}
+	module := goMod.Modules[index]
+	requirement := "direct"
+	if module.Indirect {
+		requirement = "indirect"
+	}
+	c.trace.printf("module: %s@v%s (%s)", module.Path, module.Version, requirement)
+	if module.Tool {
+		c.trace.printf("module provides a tool declared with 'tool' directive")
+	}
+	if module.Replace != nil {
+		c.trace.printf("replace directive: %s", describeReplace(module.Replace))
+	}
+	if module.IsLocal() {
+		c.trace.printf("module was replaced with a local path, its metrics are not calculated")
+		return nil
+	}
+	if !c.ageLimit.IsZero() {
+		c.trace.printf("age limit: only versions published before %s are considered", c.ageLimit.Format(time.DateOnly))
+	}
+
+	if err = c.runForModule(module); err != nil {
+		return err
+	}
+	c.trace.printf("result: libyear %.2f", module.Libyear)
+	if c.optionIsSet(OptionShowReleases) {
+		c.trace.printf("result: releases %d", module.ReleasesDiff)
+	}
+	if c.optionIsSet(OptionShowVersions) {
+		c.trace.printf("result: versions %s", module.VersionsDiff)
+	}
+	return nil
+}
+
+// tracer writes the steps taken to calculate module's metrics.
+// It is only set in explain mode, all its methods are no-op for nil tracer.
+type tracer struct {
+	w io.Writer
+}
+
+func (t *tracer) printf(format string, args ...any) {
+	if t == nil {
+		return
+	}
+	_, _ = fmt.Fprintf(t.w, format+"\n", args...)
+}
+
+// versions writes the list of versions, prefixed with the message.
+func (t *tracer) versions(msg string, versions []*semver.Version) {
+	if t == nil {
+		return
+	}
+	t.printf("%s: %s", msg, formatVersions(versions))
+}
+
+// filtered writes the versions which were removed from the list, if there were any.
+func (t *tracer) filtered(reason string, before, after []*semver.Version) {
+	if t == nil || len(before) == len(after) {
+		return
+	}
+	removed := make([]*semver.Version, 0, len(before)-len(after))
+	for _, version := range before {
+		if !isAnyOf(version, after) {
+			removed = append(removed, version)
+		}
+	}
+	t.versions("filtered out ("+reason+")", removed)
+}
+
+func formatVersions(versions []*semver.Version) string {
+	if len(versions) == 0 {
+		return "none"
+	}
+	formatted := make([]string, 0, len(versions))
+	for _, version := range versions {
+		formatted = append(formatted, "v"+version.String())
+	}
+	return strings.Join(formatted, ", ")
+}
+
+func describeRepo(repo any) string {
+	if stringer, ok := repo.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return fmt.Sprintf("%T", repo)
+}
+
+func describeReplace(replace *internal.Replace) string {
+	description := replace.Kind.String()
+	if replace.Path != "" {
+		description += " " + replace.Path
+	}
+	if replace.Version != nil {
+		description += "@v" + replace.Version.String()
+	}
+	return description
+}
@@ -0,0 +1,81 @@
+package libyear
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/nieomylnieja/go-libyear/internal"
+	"github.com/nieomylnieja/go-libyear/internal/mocks"
+)
+
+func TestCommand_Explain(t *testing.T) {
+	const goMod = `module github.com/nieomylnieja/test
+
+go 1.21
+
+require github.com/a/b v1.0.0
+
+exclude github.com/a/b v1.1.0
+`
+	ctrl := gomock.NewController(t)
+	path := "github.com/a/b"
+	modulesRepo := mocks.NewMockModulesRepo(ctrl)
+	expectModFiles(modulesRepo)
+	modulesRepo.EXPECT().
+		GetInfo(path, semver.MustParse("v1.0.0")).
+		Times(1).
+		Return(&internal.Module{Time: mustParseTime(t, "2023-01-01")}, nil)
+	modulesRepo.EXPECT().
+		GetLatestInfo(path).
+		Times(1).
+		Return(&internal.Module{Path: path, Version: semver.MustParse("v1.2.0"), Time: mustParseTime(t, "2024-01-01")}, nil)
+	modulesRepo.EXPECT().
+		GetVersions(path).
+		Times(1).
+		Return([]*semver.Version{
+			semver.MustParse("v1.0.0"),
+			semver.MustParse("v1.1.0"),
+			semver.MustParse("v1.2.0-rc.1"),
+			semver.MustParse("v1.2.0"),
+		}, nil)
+	cmd := Command{
+		source: bytesSource(goMod),
+		repo:   modulesRepo,
+		vcs:    NewVCSRegistry(t.TempDir()),
+		opts:   OptionShowReleases,
+	}
+	var buf bytes.Buffer
+
+	err := cmd.Explain(context.Background(), path, &buf)
+
+	require.NoError(t, err)
+	trace := buf.String()
+	for _, expected := range []string{
+		"module: github.com/a/b@v1.0.0 (direct)\n",
+		"current version release date: 2023-01-01\n",
+		"probing path github.com/a/b\n",
+		"latest: github.com/a/b@v1.2.0, released 2024-01-01\n",
+		"filtered out (excluded by go.mod): v1.1.0\n",
+		"filtered out (prerelease policy: current): v1.2.0-rc.1\n",
+		"libyear = (2024-01-01 - 2023-01-01) / 365 days = 1.00\n",
+		"releases between v1.0.0 and v1.2.0 = 1\n",
+		"result: libyear 1.00\n",
+		"result: releases 1\n",
+	} {
+		assert.Contains(t, trace, expected)
+	}
+}
+
+func TestCommand_Explain_NotRequired(t *testing.T) {
+	cmd := Command{source: bytesSource("module github.com/nieomylnieja/test\n\ngo 1.21\n")}
+
+	err := cmd.Explain(context.Background(), "github.com/a/b", &bytes.Buffer{})
+
+	require.EqualError(t, err, "module github.com/a/b is not required by github.com/nieomylnieja/test")
+}
@@ -0,0 +1,123 @@
+package libyear
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/Masterminds/semver"
+	"golang.org/x/mod/module"
+
+	"github.com/nieomylnieja/go-libyear/internal"
+)
+
+const incompatibleMetadata = "incompatible"
+
+// findLatestIncompatible looks for +incompatible versions greater than the current one.
+// Depending on the source, +incompatible versions might not be considered for latest,
+// e.g. if the module has a go.mod file in its latest v0 or v1 version.
+// If there are no such versions, latest is returned.
+func (c Command) findLatestIncompatible(
+	repo ModulesRepo,
+	current, latest *internal.Module,
+) (*internal.Module, error) {
+	isPrerelease := current.Version.Prerelease() != ""
+	versions, err := c.getVersionsForPath(repo, current.Path, isPrerelease)
+	if err != nil {
+		if err == errNoVersions {
+			return latest, nil
+		}
+		return nil, err
+	}
+	versions = c.filterExcluded(current.Path, versions)
+	sort.Sort(sort.Reverse(semver.Collection(versions)))
+	for _, version := range versions {
+		if !version.GreaterThan(latest.Version) || !version.GreaterThan(current.Version) {
+			break
+		}
+		if version.Metadata() != incompatibleMetadata ||
+			(!c.prereleasesAllowed(current.Path, current.Version) && internal.IsPrerelease(version)) {
+			continue
+		}
+		candidate, err := repo.GetInfo(current.Path, version)
+		if err != nil {
+			return nil, err
+		}
+		if !c.ageLimit.IsZero() && candidate.Time.After(c.ageLimit) {
+			continue
+		}
+		return candidate, nil
+	}
+	return latest, nil
+}
+
+// findMigration finds the latest version of the module-aware major version path (/vN)
+// which the +incompatible module migrated to.
+// Modules adopt Go modules either with their last +incompatible major version or the next one,
+// only if one of these exists, greater major versions are looked up.
+// If the module never migrated, nil is returned.
+func (c Command) findMigration(repo ModulesRepo, current, latest *internal.Module) (*internal.Module, error) {
+	// Latest major was already found, there's no need to look for it again.
+	if latest.Path != current.Path {
+		return latest, nil
+	}
+	// Only paths without major version suffix, excluding gopkg.in, can have +incompatible versions.
+	if _, pathMajor, ok := module.SplitPathVersion(current.Path); !ok || pathMajor != "" {
+		return nil, nil
+	}
+	major := max(current.Version.Major(), latest.Version.Major(), 2)
+	var migration *internal.Module
+	for _, candidate := range []int64{major, major + 1} {
+		lts, err := c.getLatestForPath(repo, majorVersionPath(current.Path, candidate))
+		if err != nil {
+			return nil, err
+		}
+		if lts != nil {
+			migration, major = lts, candidate
+			break
+		}
+	}
+	if migration == nil {
+		return nil, nil
+	}
+	for {
+		lts, err := c.getLatestForPath(repo, majorVersionPath(current.Path, major+1))
+		if err != nil {
+			return nil, err
+		}
+		if lts == nil {
+			return migration, nil
+		}
+		migration = lts
+		major++
+	}
+}
+
+// getLatestForPath returns the latest, not excluded version in the given path.
+// If the path does not exist or has no versions matching the age limit, nil is returned.
+func (c Command) getLatestForPath(repo ModulesRepo, path string) (*internal.Module, error) {
+	var (
+		latest *internal.Module
+		err    error
+	)
+	if c.ageLimit.IsZero() {
+		latest, err = repo.GetLatestInfo(path)
+	} else {
+		latest, err = c.findLatestBefore(repo, path, nil)
+	}
+	if err != nil {
+		if internal.IsNotFound(err) || err == errNoVersions || err == errNoMatchingVersions {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if c.excludes.IsExcluded(path, latest.Version) {
+		return c.findGreatestAllowed(repo, path, latest, func(v *semver.Version) bool {
+			return c.excludes.IsExcluded(path, v)
+		})
+	}
+	return latest, nil
+}
+
+func majorVersionPath(path string, major int64) string {
+	return path + "/v" + strconv.FormatInt(major, 10)
+}
@@ -0,0 +1,141 @@
+package libyear
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/nieomylnieja/go-libyear/internal"
+	"github.com/nieomylnieja/go-libyear/internal/mocks"
+)
+
+func TestCommand_GetLatestInfo_Incompatible(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	path := "github.com/docker/docker"
+	modulesRepo := mocks.NewMockModulesRepo(ctrl)
+	expectModFiles(modulesRepo)
+	modulesRepo.EXPECT().
+		GetLatestInfo(path).
+		Times(1).
+		Return(&internal.Module{Path: path, Version: semver.MustParse("v1.13.1"), Time: mustParseTime(t, "2017-02-08")}, nil)
+	modulesRepo.EXPECT().
+		GetVersions(path).
+		Times(1).
+		Return([]*semver.Version{
+			semver.MustParse("v1.13.1"),
+			semver.MustParse("v20.10.0+incompatible"),
+			semver.MustParse("v20.10.1+incompatible"),
+			semver.MustParse("v20.10.2-rc1+incompatible"),
+		}, nil)
+	modulesRepo.EXPECT().
+		GetInfo(path, semver.MustParse("v20.10.1+incompatible")).
+		Times(1).
+		Return(&internal.Module{
+			Path:    path,
+			Version: semver.MustParse("v20.10.1+incompatible"),
+			Time:    mustParseTime(t, "2020-12-15"),
+		}, nil)
+	cmd := Command{}
+
+	latest, err := cmd.getLatestInfo(&internal.Module{
+		Path:    path,
+		Version: semver.MustParse("v20.10.0+incompatible"),
+		Time:    mustParseTime(t, "2020-12-08"),
+	}, modulesRepo)
+
+	require.NoError(t, err)
+	assert.Equal(t, "20.10.1+incompatible", latest.Version.String())
+	assert.Equal(t, []string{path}, latest.AllPaths)
+}
+
+func TestCommand_FindMigration(t *testing.T) {
+	path := "github.com/go-playground/validator"
+	current := &internal.Module{
+		Path:    path,
+		Version: semver.MustParse("v8.18.2+incompatible"),
+		Time:    mustParseTime(t, "2017-07-30"),
+	}
+	latest := &internal.Module{
+		Path:    path,
+		Version: semver.MustParse("v9.31.0+incompatible"),
+		Time:    mustParseTime(t, "2019-12-25"),
+	}
+	notFound := &internal.NotFoundError{Err: errors.New("no matching versions for: " + path)}
+
+	t.Run("next major", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		modulesRepo := mocks.NewMockModulesRepo(ctrl)
+		modulesRepo.EXPECT().GetLatestInfo(path+"/v9").Times(1).Return(nil, notFound)
+		modulesRepo.EXPECT().
+			GetLatestInfo(path+"/v10").
+			Times(1).
+			Return(&internal.Module{
+				Path:    path + "/v10",
+				Version: semver.MustParse("v10.17.0"),
+				Time:    mustParseTime(t, "2024-01-14"),
+			}, nil)
+		modulesRepo.EXPECT().GetLatestInfo(path+"/v11").Times(1).Return(nil, notFound)
+
+		migration, err := Command{}.findMigration(modulesRepo, current, latest)
+
+		require.NoError(t, err)
+		require.NotNil(t, migration)
+		assert.Equal(t, path+"/v10", migration.Path)
+		assert.Equal(t, "10.17.0", migration.Version.String())
+	})
+	t.Run("never migrated", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		modulesRepo := mocks.NewMockModulesRepo(ctrl)
+		modulesRepo.EXPECT().GetLatestInfo(path+"/v9").Times(1).Return(nil, notFound)
+		modulesRepo.EXPECT().
+			GetLatestInfo(path+"/v10").
+			Times(1).
+			Return(nil, &internal.ResponseError{StatusCode: 410})
+
+		migration, err := Command{}.findMigration(modulesRepo, current, latest)
+
+		require.NoError(t, err)
+		assert.Nil(t, migration)
+	})
+	t.Run("latest major already found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		modulesRepo := mocks.NewMockModulesRepo(ctrl)
+		latestMajor := &internal.Module{Path: path + "/v10", Version: semver.MustParse("v10.17.0")}
+
+		migration, err := Command{}.findMigration(modulesRepo, current, latestMajor)
+
+		require.NoError(t, err)
+		assert.Equal(t, latestMajor, migration)
+	})
+	t.Run("unexpected error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		modulesRepo := mocks.NewMockModulesRepo(ctrl)
+		modulesRepo.EXPECT().
+			GetLatestInfo(path+"/v9").
+			Times(1).
+			Return(nil, &internal.ResponseError{StatusCode: 500})
+
+		_, err := Command{}.findMigration(modulesRepo, current, latest)
+
+		require.Error(t, err)
+	})
+	t.Run("private module", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		privatePath := "github.com/nieomylnieja/private"
+		// Only the module's own path is registered, probed major version paths are not.
+		git := internal.NewLightweightGitVCS(t.TempDir(), mocks.NewMockGitCmdI(ctrl))
+		canHandle, err := git.CanHandle(privatePath)
+		require.NoError(t, err)
+		require.True(t, canHandle)
+		privateCurrent := &internal.Module{Path: privatePath, Version: semver.MustParse("v2.1.0+incompatible")}
+
+		migration, err := Command{}.findMigration(git, privateCurrent, privateCurrent)
+
+		require.NoError(t, err)
+		assert.Nil(t, migration)
+	})
+}
@@ -0,0 +1,426 @@
+package internal
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// NewHTTPAuthFromEnv creates [HTTPAuth] for the given GOAUTH value, or GOAUTH environment variable if it's empty.
+func NewHTTPAuthFromEnv(goAuth string, logger *slog.Logger) (*HTTPAuth, error) {
+	if goAuth == "" {
+		goAuth = os.Getenv("GOAUTH")
+	}
+	return NewHTTPAuth(goAuth, logger)
+}
+
+// NewHTTPAuth creates [HTTPAuth] which reads the credentials with the given authentication commands,
+// following the GOAUTH syntax (see 'go help goauth'): a semicolon-separated list of 'off', 'netrc',
+// 'git <dir>' or custom commands. If goAuth is empty, 'netrc' is used.
+// Failed commands are reported with the logger, if it's nil, [slog.Default] is used.
+func NewHTTPAuth(goAuth string, logger *slog.Logger) (*HTTPAuth, error) {
+	if goAuth == "" {
+		goAuth = "netrc"
+	}
+	auth := &HTTPAuth{
+		static:      make(map[string]http.Header),
+		credentials: make(map[string]http.Header),
+		logger:      loggerOrDefault(logger),
+	}
+	commands := strings.Split(goAuth, ";")
+	for _, command := range commands {
+		words := strings.Fields(command)
+		if len(words) == 0 {
+			return nil, errors.Errorf("GOAUTH contains an empty command: %q", goAuth)
+		}
+		switch words[0] {
+		case "off":
+			if len(commands) != 1 {
+				return nil, errors.Errorf("GOAUTH=off cannot be combined with other commands: %q", goAuth)
+			}
+			return auth, nil
+		case "git":
+			if len(words) != 2 || !filepath.IsAbs(words[1]) {
+				return nil, errors.Errorf("GOAUTH=git requires an absolute path to the git working directory: %q",
+					command)
+			}
+		}
+		auth.commands = append(auth.commands, words)
+		auth.refreshable = auth.refreshable || words[0] != "netrc"
+	}
+	return auth, nil
+}
+
+// HTTPAuth provides the credentials of HTTP requests.
+// Credentials are keyed by URL prefixes without the scheme, e.g. 'proxy.example.com/go',
+// the credentials of the longest matching prefix are used.
+// Credentials read with GOAUTH commands are only sent over HTTPS,
+// while the ones set explicitly with [HTTPAuth.SetHeader] are sent over any protocol.
+type HTTPAuth struct {
+	commands [][]string
+	// refreshable is set if any of the commands can provide new credentials for a specific URL.
+	refreshable bool
+	logger      *slog.Logger
+	initOnce    sync.Once
+	mu          sync.Mutex
+	// static credentials take precedence over the ones read with commands.
+	static      map[string]http.Header
+	credentials map[string]http.Header
+}
+
+// SetHeader sets the headers, e.g. Authorization, sent with each request matching the URL prefix.
+func (a *HTTPAuth) SetHeader(prefix string, header http.Header) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.static[normalizeAuthPrefix(prefix)] = header.Clone()
+}
+
+// SetBearerToken sets the bearer token sent with each request matching the URL prefix.
+func (a *HTTPAuth) SetBearerToken(prefix, token string) {
+	a.SetHeader(prefix, http.Header{"Authorization": []string{"Bearer " + token}})
+}
+
+// addCredentials adds the headers of the most specific credentials matching the request's URL.
+// Headers already set by the caller are not overwritten.
+func (a *HTTPAuth) addCredentials(req *http.Request) {
+	a.initOnce.Do(func() { a.runCommands("", nil) })
+	header, found := a.lookup(req.URL, a.static)
+	if !found && req.URL.Scheme == "https" {
+		header, found = a.lookup(req.URL, a.credentials)
+	}
+	if !found {
+		return
+	}
+	for key, values := range header {
+		if req.Header.Get(key) != "" {
+			continue
+		}
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+}
+
+// refresh runs the authentication commands for the URL which was responded to with the given 4xx response.
+// It reports whether new credentials matching the URL were provided.
+func (a *HTTPAuth) refresh(req *http.Request, resp *http.Response) bool {
+	if req.URL.Scheme != "https" || !a.refreshable {
+		return false
+	}
+	if _, found := a.lookup(req.URL, a.static); found {
+		return false
+	}
+	previous, _ := a.lookup(req.URL, a.credentials)
+	a.runCommands(credentialsURL(req.URL), resp)
+	current, found := a.lookup(req.URL, a.credentials)
+	return found && !reflect.DeepEqual(previous, current)
+}
+
+func (a *HTTPAuth) lookup(u *url.URL, credentials map[string]http.Header) (http.Header, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	prefix := normalizeAuthPrefix(u.Host + u.EscapedPath())
+	for {
+		if header, ok := credentials[prefix]; ok {
+			return header, true
+		}
+		i := strings.LastIndexByte(prefix, '/')
+		if i == -1 {
+			break
+		}
+		prefix = prefix[:i]
+	}
+	// Machines listed in .netrc don't include the port.
+	if u.Port() != "" {
+		if header, ok := credentials[u.Hostname()]; ok {
+			return header, true
+		}
+	}
+	return nil, false
+}
+
+// runCommands runs the authentication commands, if rawURL is empty, only the commands
+// which don't need a specific URL are run. The commands are run in reverse order,
+// so that the credentials provided by the earlier ones take precedence.
+func (a *HTTPAuth) runCommands(rawURL string, resp *http.Response) {
+	for i := len(a.commands) - 1; i >= 0; i-- {
+		command := a.commands[i]
+		var (
+			credentials map[string]http.Header
+			err         error
+		)
+		switch command[0] {
+		case "netrc":
+			credentials, err = readNetrcCredentials()
+		case "git":
+			if rawURL == "" {
+				// 'git credential' can't list the credentials, it requires a URL.
+				continue
+			}
+			credentials, err = runGitCredentialFill(command[1], rawURL)
+		default:
+			credentials, err = runAuthCommand(command, rawURL, resp)
+		}
+		if err != nil {
+			a.logger.Warn("GOAUTH command failed", "command", strings.Join(command, " "), "error", err)
+			continue
+		}
+		a.mu.Lock()
+		for prefix, header := range credentials {
+			a.credentials[normalizeAuthPrefix(prefix)] = header
+		}
+		a.mu.Unlock()
+	}
+}
+
+func normalizeAuthPrefix(prefix string) string {
+	prefix = strings.TrimPrefix(prefix, "https://")
+	prefix = strings.TrimPrefix(prefix, "http://")
+	return strings.TrimSuffix(prefix, "/")
+}
+
+// readNetrcCredentials reads basic auth credentials from $NETRC or ~/.netrc file.
+// If the same machine is listed multiple times, the first entry wins.
+func readNetrcCredentials() (map[string]http.Header, error) {
+	path := os.Getenv("NETRC")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(home, ".netrc")
+		if runtime.GOOS == "windows" {
+			if _, err = os.Stat(filepath.Join(home, "_netrc")); err == nil {
+				path = filepath.Join(home, "_netrc")
+			}
+		}
+	}
+	// #nosec G304
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	credentials := make(map[string]http.Header)
+	for _, entry := range parseNetrc(string(data)) {
+		if _, ok := credentials[entry.machine]; !ok {
+			credentials[entry.machine] = basicAuthHeader(entry.login, entry.password)
+		}
+	}
+	return credentials, nil
+}
+
+type netrcEntry struct {
+	machine  string
+	login    string
+	password string
+}
+
+// parseNetrc parses .netrc file contents, entries without login or password are skipped.
+// The 'default' entry is not supported, as it would send the credentials to any host.
+func parseNetrc(data string) []netrcEntry {
+	var (
+		entries []netrcEntry
+		entry   netrcEntry
+		inMacro bool
+	)
+	for _, line := range strings.Split(data, "\n") {
+		if inMacro {
+			// Macro definition ends with an empty line.
+			inMacro = strings.TrimSpace(line) != ""
+			continue
+		}
+		fields := strings.Fields(line)
+	tokens:
+		for i := 0; i < len(fields); i++ {
+			switch fields[i] {
+			case "default":
+				// It must be the last entry.
+				return entries
+			case "macdef":
+				inMacro = true
+				break tokens
+			case "machine", "login", "password", "account":
+				if i+1 == len(fields) {
+					break tokens
+				}
+				value := fields[i+1]
+				i++
+				switch fields[i-1] {
+				case "machine":
+					entry = netrcEntry{machine: value}
+				case "login":
+					entry.login = value
+				case "password":
+					entry.password = value
+				}
+			}
+			if entry.machine != "" && entry.login != "" && entry.password != "" {
+				entries = append(entries, entry)
+				entry = netrcEntry{}
+			}
+		}
+	}
+	return entries
+}
+
+// runGitCredentialFill reads the credentials for the URL with 'git credential fill' run in dir.
+func runGitCredentialFill(dir, rawURL string) (map[string]http.Header, error) {
+	cmd := exec.Command("git", "credential", "fill")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader("url=" + rawURL + "\n")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "'git credential fill' failed")
+	}
+	var (
+		prefix             url.URL
+		username, password string
+	)
+	for _, line := range strings.Split(string(out), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "protocol":
+			prefix.Scheme = value
+		case "host":
+			prefix.Host = value
+		case "path":
+			prefix.Path = value
+		case "username":
+			username = value
+		case "password":
+			password = value
+		}
+	}
+	if prefix.Host == "" || !strings.HasPrefix(rawURL, prefix.String()) {
+		return nil, errors.New("'git credential fill' returned credentials for a different URL")
+	}
+	return map[string]http.Header{prefix.String(): basicAuthHeader(username, password)}, nil
+}
+
+// runAuthCommand runs the custom GOAUTH command and parses its output.
+// If rawURL is set, it's passed as the last argument and the response is written to the command's stdin.
+func runAuthCommand(command []string, rawURL string, resp *http.Response) (map[string]http.Header, error) {
+	args := command[1:]
+	if rawURL != "" {
+		args = append(args[:len(args):len(args)], rawURL)
+	}
+	// #nosec G204
+	cmd := exec.Command(command[0], args...)
+	if resp != nil {
+		var stdin bytes.Buffer
+		stdin.WriteString(resp.Proto + " " + resp.Status + "\n")
+		_ = resp.Header.Write(&stdin)
+		stdin.WriteString("\n")
+		cmd.Stdin = &stdin
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "command failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return parseAuthCommandOutput(string(out))
+}
+
+// parseAuthCommandOutput parses the output of custom GOAUTH command, which consists of credential sets:
+// one or more URL lines followed by an empty line, and header lines followed by an empty line.
+func parseAuthCommandOutput(data string) (map[string]http.Header, error) {
+	credentials := make(map[string]http.Header)
+	for data != "" {
+		var (
+			line     string
+			complete bool
+			prefixes []string
+		)
+		for {
+			if line, data, complete = strings.Cut(data, "\n"); !complete {
+				return nil, errors.New("invalid GOAUTH command output: missing empty line after URLs")
+			}
+			if line == "" {
+				break
+			}
+			u, err := url.ParseRequestURI(line)
+			if err != nil || u.Scheme != "https" {
+				return nil, errors.Errorf("invalid GOAUTH command output: expected HTTPS URL, got %q", line)
+			}
+			prefixes = append(prefixes, u.String())
+		}
+		header := make(http.Header)
+		for {
+			if line, data, complete = strings.Cut(data, "\n"); !complete {
+				return nil, errors.New("invalid GOAUTH command output: missing empty line after headers")
+			}
+			if line == "" {
+				break
+			}
+			name, value, ok := strings.Cut(line, ": ")
+			if !ok || name == "" {
+				// The line might contain credentials, don't include it in the error.
+				return nil, errors.New("invalid GOAUTH command output: invalid header line")
+			}
+			header.Add(name, strings.TrimSpace(value))
+		}
+		for _, prefix := range prefixes {
+			credentials[prefix] = header.Clone()
+		}
+	}
+	return credentials, nil
+}
+
+func basicAuthHeader(username, password string) http.Header {
+	req := http.Request{Header: make(http.Header)}
+	req.SetBasicAuth(username, password)
+	return req.Header
+}
+
+// authTransport implements [http.RoundTripper] which adds the credentials to the requests.
+type authTransport struct {
+	base http.RoundTripper
+	auth *HTTPAuth
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	authReq := req.Clone(req.Context())
+	t.auth.addCredentials(authReq)
+	resp, err := t.base.RoundTrip(authReq)
+	if err != nil || resp.StatusCode < 400 || resp.StatusCode >= 500 {
+		return resp, err
+	}
+	// Only requests which can be safely repeated are retried with refreshed credentials.
+	if req.Method != http.MethodGet && req.Method != http.MethodHead ||
+		req.Body != nil && req.Body != http.NoBody {
+		return resp, nil
+	}
+	if !t.auth.refresh(req, resp) {
+		return resp, nil
+	}
+	_ = resp.Body.Close()
+	authReq = req.Clone(req.Context())
+	t.auth.addCredentials(authReq)
+	return t.base.RoundTrip(authReq)
+}
+
+// credentialsURL returns the URL passed to the authentication commands, without user info and query.
+func credentialsURL(u *url.URL) string {
+	stripped := *u
+	stripped.User = nil
+	stripped.RawQuery = ""
+	stripped.Fragment = ""
+	return stripped.String()
+}
@@ -0,0 +1,202 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHTTPAuth(t *testing.T) {
+	auth, err := NewHTTPAuth("", nil)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"netrc"}}, auth.commands)
+	assert.False(t, auth.refreshable)
+
+	auth, err = NewHTTPAuth("netrc; git /repo;my-auth --flag", nil)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"netrc"}, {"git", "/repo"}, {"my-auth", "--flag"}}, auth.commands)
+	assert.True(t, auth.refreshable)
+
+	auth, err = NewHTTPAuth("off", nil)
+	require.NoError(t, err)
+	assert.Empty(t, auth.commands)
+
+	_, err = NewHTTPAuth("off;netrc", nil)
+	assert.EqualError(t, err, `GOAUTH=off cannot be combined with other commands: "off;netrc"`)
+	_, err = NewHTTPAuth("git repo", nil)
+	assert.EqualError(t, err, `GOAUTH=git requires an absolute path to the git working directory: "git repo"`)
+	_, err = NewHTTPAuth("netrc;", nil)
+	assert.EqualError(t, err, `GOAUTH contains an empty command: "netrc;"`)
+}
+
+func TestParseNetrc(t *testing.T) {
+	entries := parseNetrc(`
+machine proxy.example.com login user password secret
+machine incomplete.example.com login user
+macdef init
+machine macro.example.com login macro password macro
+
+machine repo.example.com
+  login git
+  password token
+machine proxy.example.com login other password other
+default login anonymous password anonymous
+machine ignored.example.com login user password secret
+`)
+	assert.Equal(t, []netrcEntry{
+		{machine: "proxy.example.com", login: "user", password: "secret"},
+		{machine: "repo.example.com", login: "git", password: "token"},
+		{machine: "proxy.example.com", login: "other", password: "other"},
+	}, entries)
+}
+
+func TestParseAuthCommandOutput(t *testing.T) {
+	credentials, err := parseAuthCommandOutput("https://a.example.com\nhttps://b.example.com/go\n\n" +
+		"Authorization: Bearer a\n\nhttps://c.example.com\n\nX-Token: c\nX-User: user\n\n")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]http.Header{
+		"https://a.example.com":    {"Authorization": {"Bearer a"}},
+		"https://b.example.com/go": {"Authorization": {"Bearer a"}},
+		"https://c.example.com":    {"X-Token": {"c"}, "X-User": {"user"}},
+	}, credentials)
+
+	_, err = parseAuthCommandOutput("http://a.example.com\n\nAuthorization: Bearer a\n\n")
+	assert.EqualError(t, err, `invalid GOAUTH command output: expected HTTPS URL, got "http://a.example.com"`)
+	_, err = parseAuthCommandOutput("https://a.example.com\n\nBearer secret\n\n")
+	assert.EqualError(t, err, "invalid GOAUTH command output: invalid header line")
+	assert.NotContains(t, err.Error(), "secret")
+	_, err = parseAuthCommandOutput("https://a.example.com\n\nAuthorization: Bearer a\n")
+	assert.EqualError(t, err, "invalid GOAUTH command output: missing empty line after headers")
+}
+
+func TestHTTPAuth_Lookup(t *testing.T) {
+	auth, err := NewHTTPAuth("off", nil)
+	require.NoError(t, err)
+	auth.SetBearerToken("https://example.com", "host")
+	auth.SetBearerToken("example.com/go/", "path")
+	auth.SetBearerToken("localhost", "hostname")
+
+	tests := map[string]string{
+		"https://example.com/go/github.com/a/b/@v/list": "Bearer path",
+		"https://example.com/gopher":                    "Bearer host",
+		"http://example.com/":                           "Bearer host",
+		"http://localhost:8080/go.mod":                  "Bearer hostname",
+		"https://other.com/go":                          "",
+	}
+	for rawURL, expected := range tests {
+		u, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		header, _ := auth.lookup(u, auth.static)
+		assert.Equal(t, expected, header.Get("Authorization"), rawURL)
+	}
+}
+
+func TestHTTPClient_NetrcAuth(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "user" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	netrc := filepath.Join(t.TempDir(), ".netrc")
+	require.NoError(t, os.WriteFile(netrc, []byte("machine "+u.Hostname()+" login user password secret\n"), 0o600))
+	t.Setenv("NETRC", netrc)
+	auth, err := NewHTTPAuth("netrc", nil)
+	require.NoError(t, err)
+	config := newTestHTTPConfig()
+	config.Auth = auth
+
+	resp, err := NewHTTPClient(srv.Client(), config).Get(srv.URL)
+
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestHTTPClient_BearerToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+	auth, err := NewHTTPAuth("off", nil)
+	require.NoError(t, err)
+	auth.SetBearerToken(srv.URL, "secret")
+	config := newTestHTTPConfig()
+	config.Auth = auth
+
+	resp, err := NewHTTPClient(nil, config).Get(srv.URL + "/go.mod")
+
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestHTTPClient_GoAuthCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test uses a shell script")
+	}
+	var attempts atomic.Int32
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+	// The credentials are only provided for a specific URL, after the server responded with 401.
+	script := filepath.Join(t.TempDir(), "auth.sh")
+	require.NoError(t, os.WriteFile(script, []byte(`#!/bin/sh
+[ -z "$1" ] && exit 0
+head -n 1 | grep -q 401 || exit 1
+printf '%s\n\nAuthorization: Bearer secret\n\n' "$1"
+`), 0o700))
+	auth, err := NewHTTPAuth(script, nil)
+	require.NoError(t, err)
+	config := newTestHTTPConfig()
+	config.Auth = auth
+	client := NewHTTPClient(srv.Client(), config)
+
+	resp, err := client.Get(srv.URL + "/go.mod?token=query")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), attempts.Load())
+
+	// Credentials are reused for the subsequent requests.
+	resp, err = client.Get(srv.URL + "/go.mod")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), attempts.Load())
+}
+
+func TestGoProxyClient_RedactsCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	u.User = url.UserPassword("user", "secret")
+	t.Setenv("GOPROXY", u.String())
+	client, err := NewGoProxyClient(NewHTTPClient(nil, newTestHTTPConfig()), false, CacheConfig{})
+	require.NoError(t, err)
+
+	_, err = client.GetVersions("github.com/a/b")
+
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret")
+	assert.NotContains(t, client.String(), "secret")
+}
@@ -2,161 +2,385 @@ package internal
 
 import (
 	"encoding/json"
-	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
-	"sync"
+	"slices"
+	"sync/atomic"
 	"time"
 
 	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
 )
 
 const defaultCacheFileName = "modules"
 
+// DefaultCacheTTL is the default time after which cached version lists and latest versions are refreshed.
+const DefaultCacheTTL = 24 * time.Hour
+
+// CacheConfig configures the modules cache.
+// Information about specific versions never changes and is cached indefinitely,
+// while version lists and latest versions are only cached for [CacheConfig.TTL].
+type CacheConfig struct {
+	// FilePath is the path to the cache file, if empty, the default location is used.
+	FilePath string
+	// TTL is the time after which cached version lists and latest versions are refreshed.
+	// If not set, [DefaultCacheTTL] is used.
+	TTL time.Duration
+	// Refresh ignores cached version lists and latest versions, fetched ones are cached.
+	Refresh bool
+	// Bypass disables caching of version lists and latest versions.
+	Bypass bool
+	// Backend stores the cache entries, if not set, [FileCacheBackend] is used.
+	Backend CacheBackend
+	// GoSum is used to verify the cached go.mod files, in addition to their stored hashes.
+	GoSum GoSum
+	// Logger reports the problems with cached entries, if not set, [slog.Default] is used.
+	Logger *slog.Logger
+}
+
 type modulesCache interface {
 	Load(path string, version *semver.Version) (*Module, bool)
 	Save(m *Module) error
+	LoadVersions(path string) ([]*semver.Version, bool)
+	SaveVersions(path string, versions []*semver.Version) error
+	LoadLatest(path string) (*Module, bool)
+	SaveLatest(m *Module) error
+	LoadModFile(path string, version *semver.Version) ([]byte, bool)
+	SaveModFile(path string, version *semver.Version, data []byte) error
+	SaveRunStats() error
 }
 
-type cachePersistenceLayer interface {
-	Save(module persistedModule) error
-	Load() ([]persistedModule, error)
+// CacheBackend stores the cache entries.
+// It must be safe for concurrent use, implementations which are shared by multiple processes
+// must also synchronize the access between them.
+type CacheBackend interface {
+	// Get returns the entry stored under the key.
+	Get(key CacheKey) (entry *CacheEntry, found bool, err error)
+	// Put stores the entry, replacing the one stored under the same key.
+	Put(entry *CacheEntry) error
 }
 
-func NewCache(filePath string) (*Cache, error) {
-	persistence, err := newFilePersistence(filePath)
-	if err != nil {
-		return nil, err
+// CacheEntryKind distinguishes the kinds of cached information.
+type CacheEntryKind string
+
+const (
+	// CacheEntryModule holds the release time of a specific module version, it never changes.
+	CacheEntryModule CacheEntryKind = "module"
+	// CacheEntryVersions holds the list of module's versions.
+	CacheEntryVersions CacheEntryKind = "versions"
+	// CacheEntryLatest holds the latest version of the module and its release time.
+	CacheEntryLatest CacheEntryKind = "latest"
+	// CacheEntryModFile holds the go.mod file of a specific module version, it never changes.
+	CacheEntryModFile CacheEntryKind = "modfile"
+)
+
+// CacheKey identifies [CacheEntry].
+// Version is only set for [CacheEntryModule] and [CacheEntryModFile] entries.
+type CacheKey struct {
+	Kind    CacheEntryKind
+	Path    string
+	Version *semver.Version
+}
+
+func (k CacheKey) String() string {
+	if k.Version == nil {
+		return string(k.Kind) + ":" + k.Path
+	}
+	return string(k.Kind) + ":" + k.Path + "@v" + k.Version.String()
+}
+
+// CacheEntry is a single piece of cached information about a module.
+type CacheEntry struct {
+	Kind CacheEntryKind
+	Path string
+	// Version is set for [CacheEntryModule] and [CacheEntryLatest] entries.
+	Version *semver.Version
+	// Time is the release time of the Version.
+	Time time.Time
+	// Versions is only set for [CacheEntryVersions] entries.
+	Versions []*semver.Version
+	// ModFile is the go.mod file contents, only set for [CacheEntryModFile] entries.
+	ModFile []byte
+	// Sum is the hash of ModFile, in go.sum format.
+	Sum string
+	// FetchedAt is the time the information was fetched at.
+	FetchedAt time.Time
+}
+
+// isImmutable reports whether the information held by entries of this kind never changes.
+func (k CacheEntryKind) isImmutable() bool {
+	return k == CacheEntryModule || k == CacheEntryModFile
+}
+
+// Key returns the key under which the entry is stored.
+func (e *CacheEntry) Key() CacheKey {
+	key := CacheKey{Kind: e.Kind, Path: e.Path}
+	if e.Kind.isImmutable() {
+		key.Version = e.Version
+	}
+	return key
+}
+
+func NewCache(config CacheConfig) (*Cache, error) {
+	backend := config.Backend
+	logger := loggerOrDefault(config.Logger)
+	var statsPath string
+	if backend == nil {
+		filePath, err := resolveCacheFilePath(config.FilePath)
+		if err != nil {
+			return nil, err
+		}
+		if backend, err = newFileCacheBackend(filePath, logger); err != nil {
+			return nil, err
+		}
+		statsPath = cacheStatsFilePath(filePath)
 	}
-	cache := &Cache{
-		Modules:     make(map[string]*Module),
-		rwm:         sync.RWMutex{},
-		persistence: persistence,
+	ttl := config.TTL
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
 	}
-	return cache, cache.loadFromPersistence()
+	return &Cache{
+		backend:   backend,
+		goSum:     config.GoSum,
+		logger:    logger,
+		statsPath: statsPath,
+		ttl:       ttl,
+		refresh:   config.Refresh,
+		bypass:    config.Bypass,
+		now:       time.Now,
+	}, nil
 }
 
+// Cache implements the caching policy on top of [CacheBackend].
 type Cache struct {
-	Modules     map[string]*Module
-	rwm         sync.RWMutex
-	persistence cachePersistenceLayer
+	backend   CacheBackend
+	goSum     GoSum
+	logger    *slog.Logger
+	statsPath string
+	ttl       time.Duration
+	refresh   bool
+	bypass    bool
+	now       func() time.Time
+	hits      atomic.Int64
+	misses    atomic.Int64
 }
 
-func (c *Cache) Load(path string, version *semver.Version) (module *Module, loaded bool) {
-	c.rwm.RLock()
-	defer c.rwm.RUnlock()
-	module, loaded = c.Modules[c.moduleHash(path, version)]
-	return
+func (c *Cache) Load(path string, version *semver.Version) (*Module, bool) {
+	entry, loaded := c.lookup(CacheKey{Kind: CacheEntryModule, Path: path, Version: version}, nil)
+	if !loaded {
+		return nil, false
+	}
+	return &Module{Path: entry.Path, Version: entry.Version, Time: entry.Time}, true
 }
 
 func (c *Cache) Save(m *Module) error {
-	if c.Has(m) {
+	key := CacheKey{Kind: CacheEntryModule, Path: m.Path, Version: m.Version}
+	// Information about specific versions never changes, there's no need to overwrite it.
+	if _, found, err := c.backend.Get(key); err == nil && found {
 		return nil
 	}
-	c.rwm.Lock()
-	defer c.rwm.Unlock()
-	// Second check if we were racing with another goroutine.
-	// We can't use read locks now (deadlock).
-	_, has := c.Modules[c.moduleHash(m.Path, m.Version)]
-	if has {
-		return nil
+	return c.backend.Put(&CacheEntry{
+		Kind:      CacheEntryModule,
+		Path:      m.Path,
+		Version:   m.Version,
+		Time:      m.Time,
+		FetchedAt: c.now(),
+	})
+}
+
+// LoadVersions loads the cached list of the module's versions, unless it's stale.
+// The returned list is a copy, callers are free to modify it, e.g. sort it.
+func (c *Cache) LoadVersions(path string) ([]*semver.Version, bool) {
+	entry, loaded := c.lookup(CacheKey{Kind: CacheEntryVersions, Path: path}, c.isFresh)
+	if !loaded {
+		return nil, false
 	}
-	c.Modules[c.moduleHash(m.Path, m.Version)] = m
-	if c.persistence == nil {
+	return slices.Clone(entry.Versions), true
+}
+
+// SaveVersions caches the copy of the module's versions list along with the fetch time.
+func (c *Cache) SaveVersions(path string, versions []*semver.Version) error {
+	if c.bypass {
 		return nil
 	}
-	return c.persistence.Save(persistedModule{
-		Path:    m.Path,
-		Version: m.Version,
-		Time:    m.Time,
+	return c.backend.Put(&CacheEntry{
+		Kind:      CacheEntryVersions,
+		Path:      path,
+		Versions:  slices.Clone(versions),
+		FetchedAt: c.now(),
 	})
 }
 
-func (c *Cache) Has(m *Module) bool {
-	c.rwm.RLock()
-	defer c.rwm.RUnlock()
-	_, has := c.Modules[c.moduleHash(m.Path, m.Version)]
-	return has
+// LoadLatest loads the cached latest version of the module, unless it's stale.
+func (c *Cache) LoadLatest(path string) (*Module, bool) {
+	entry, loaded := c.lookup(CacheKey{Kind: CacheEntryLatest, Path: path}, c.isFresh)
+	if !loaded {
+		return nil, false
+	}
+	return &Module{Path: entry.Path, Version: entry.Version, Time: entry.Time}, true
 }
 
-type persistedModule struct {
-	Path    string          `json:"path"`
-	Version *semver.Version `json:"version"`
-	Time    time.Time       `json:"time"`
+// SaveLatest caches the latest version of the module along with the fetch time.
+func (c *Cache) SaveLatest(m *Module) error {
+	if c.bypass {
+		return nil
+	}
+	return c.backend.Put(&CacheEntry{
+		Kind:      CacheEntryLatest,
+		Path:      m.Path,
+		Version:   m.Version,
+		Time:      m.Time,
+		FetchedAt: c.now(),
+	})
+}
+
+// LoadModFile loads the cached go.mod file of the module version.
+// The file is only loaded if its contents match the stored hash and the go.sum hash, if it's listed there.
+func (c *Cache) LoadModFile(path string, version *semver.Version) ([]byte, bool) {
+	entry, loaded := c.lookup(
+		CacheKey{Kind: CacheEntryModFile, Path: path, Version: version},
+		func(entry *CacheEntry) bool {
+			if err := c.verifyModFile(path, version, entry.ModFile, entry.Sum); err != nil {
+				c.logger.Warn("ignoring cached go.mod file", "error", err)
+				return false
+			}
+			return true
+		})
+	if !loaded {
+		return nil, false
+	}
+	return entry.ModFile, true
 }
 
-func (c *Cache) loadFromPersistence() error {
-	if c.persistence == nil {
+// SaveModFile caches the go.mod file of the module version along with its hash.
+// The file is not cached if it doesn't match the go.sum hash.
+func (c *Cache) SaveModFile(path string, version *semver.Version, data []byte) error {
+	sum, err := HashModFile(data)
+	if err != nil {
+		return err
+	}
+	if err = c.verifyModFile(path, version, data, sum); err != nil {
+		c.logger.Warn("not caching go.mod file", "error", err)
 		return nil
 	}
-	c.rwm.Lock()
-	defer c.rwm.Unlock()
-	modules, err := c.persistence.Load()
+	return c.backend.Put(&CacheEntry{
+		Kind:      CacheEntryModFile,
+		Path:      path,
+		Version:   version,
+		ModFile:   data,
+		Sum:       sum,
+		FetchedAt: c.now(),
+	})
+}
+
+func (c *Cache) verifyModFile(path string, version *semver.Version, data []byte, sum string) error {
+	actual, err := HashModFile(data)
 	if err != nil {
 		return err
 	}
-	for _, m := range modules {
-		hash := c.moduleHash(m.Path, m.Version)
-		if _, ok := c.Modules[hash]; ok {
-			fmt.Fprintf(os.Stderr, "WARN: duplicate module entry detected: %v\n", m)
-			continue
-		}
-		c.Modules[hash] = &Module{
-			Path:    m.Path,
-			Version: m.Version,
-			Time:    m.Time,
-		}
+	if actual != sum {
+		return errors.Errorf("%s@v%s/go.mod hash %s does not match the stored hash %s", path, version, actual, sum)
+	}
+	if expected, listed := c.goSum.ModFileHash(path, version); listed && actual != expected {
+		return errors.Errorf("%s@v%s/go.mod hash %s does not match go.sum hash %s", path, version, actual, expected)
 	}
 	return nil
 }
 
-func (c *Cache) moduleHash(path string, version *semver.Version) string {
-	return path + "=" + version.String()
+// SaveRunStats persists the number of cache hits and misses recorded since the cache was created.
+// The stats are overwritten on each run and can be inspected with [CacheFile.Stats].
+// They are only recorded for the default, file based, backend.
+func (c *Cache) SaveRunStats() error {
+	if c.statsPath == "" {
+		return nil
+	}
+	data, err := json.Marshal(CacheRunStats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+		Time:   c.now(),
+	})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.statsPath, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
 }
 
-func newFilePersistence(filePath string) (*filePersistence, error) {
-	if filePath == "" {
-		var err error
-		if filePath, err = GetDefaultCacheBasePath(); err != nil {
-			return nil, err
-		}
-		filePath = filepath.Join(filePath, defaultCacheFileName)
+// lookup loads the entry from the backend and records the cache hit or miss.
+// Backend errors are reported as warnings and treated as cache misses.
+// If valid is provided, entries it rejects are treated as cache misses as well.
+func (c *Cache) lookup(key CacheKey, valid func(entry *CacheEntry) bool) (entry *CacheEntry, loaded bool) {
+	defer func() { c.countLookup(loaded) }()
+	entry, found, err := c.backend.Get(key)
+	if err != nil {
+		c.logger.Warn("failed to load cache entry", "key", key.String(), "error", err)
+		return nil, false
 	}
-	// The function does an os.Stat under the hood anyway, so there's no gain in pre-checking this step.
-	if err := os.MkdirAll(filepath.Dir(filePath), 0o750); err != nil {
-		return nil, err
+	if !found || (valid != nil && !valid(entry)) {
+		return nil, false
 	}
-	// #nosec G304
-	f, err := os.OpenFile(filePath, os.O_RDWR|os.O_CREATE, 0o600)
-	if err != nil {
-		return nil, err
+	return entry, true
+}
+
+// isFresh reports whether the entry can be used with respect to refresh, bypass and TTL settings.
+func (c *Cache) isFresh(entry *CacheEntry) bool {
+	return !c.bypass && !c.refresh && c.now().Sub(entry.FetchedAt) <= c.ttl
+}
+
+func (c *Cache) countLookup(hit bool) {
+	if hit {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
 	}
-	return &filePersistence{file: f}, nil
 }
 
-type filePersistence struct {
-	file *os.File
+func derefTime(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
 }
 
-func (f filePersistence) Save(module persistedModule) error {
-	return json.NewEncoder(f.file).Encode(module)
+// resolveCacheFilePath returns the default cache file path if filePath is empty.
+func resolveCacheFilePath(filePath string) (string, error) {
+	if filePath != "" {
+		return filePath, nil
+	}
+	basePath, err := GetDefaultCacheBasePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(basePath, defaultCacheFileName), nil
 }
 
-func (f filePersistence) Load() ([]persistedModule, error) {
-	dec := json.NewDecoder(f.file)
-	modules := make([]persistedModule, 0)
-	for {
-		var m persistedModule
-		if err := dec.Decode(&m); err != nil {
-			if err == io.EOF {
-				break
-			}
-			return nil, err
-		}
-		modules = append(modules, m)
+func cacheStatsFilePath(filePath string) string {
+	return filePath + ".stats"
+}
+
+// writeFileAtomic writes the data to a temporary file which is then renamed,
+// so that the file is never observed partially written.
+func writeFileAtomic(filePath string, write func(w io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if err = write(tmp); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
 	}
-	return modules, nil
+	return os.Rename(tmp.Name(), filePath)
 }
 
 func GetDefaultCacheBasePath() (string, error) {
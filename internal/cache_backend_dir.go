@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"golang.org/x/mod/module"
+)
+
+// NewDirCacheBackend creates [DirCacheBackend] which stores the entries in dir.
+func NewDirCacheBackend(dir string) (*DirCacheBackend, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	return &DirCacheBackend{dir: dir}, nil
+}
+
+// DirCacheBackend stores each cache entry in a separate file, grouped in a directory per module.
+// The layout follows GOPROXY protocol:
+//
+//	<dir>/<module>/@v/<version>.json
+//	<dir>/<module>/@v/<version>.mod.json
+//	<dir>/<module>/@v/list.json
+//	<dir>/<module>/@latest.json
+//
+// Each file is replaced atomically, which makes the directory safe to share between processes
+// without loading the whole cache into memory.
+type DirCacheBackend struct {
+	dir string
+}
+
+func (d *DirCacheBackend) Get(key CacheKey) (*CacheEntry, bool, error) {
+	path, err := d.entryPath(key)
+	if err != nil {
+		return nil, false, err
+	}
+	// #nosec G304
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	var m persistedModule
+	if err = json.Unmarshal(data, &m); err != nil {
+		return nil, false, errors.Wrapf(err, "failed to decode %s", path)
+	}
+	return m.cacheEntry(), true, nil
+}
+
+func (d *DirCacheBackend) Put(entry *CacheEntry) error {
+	path, err := d.entryPath(entry.Key())
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(newPersistedModule(entry))
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+func (d *DirCacheBackend) entryPath(key CacheKey) (string, error) {
+	escapedPath, err := module.EscapePath(key.Path)
+	if err != nil {
+		return "", err
+	}
+	moduleDir := filepath.Join(d.dir, filepath.FromSlash(escapedPath))
+	switch key.Kind {
+	case CacheEntryModule, CacheEntryModFile:
+		if key.Version == nil {
+			return "", errors.Errorf("version is required for %s cache entry", key.Kind)
+		}
+		escapedVersion, err := module.EscapeVersion("v" + key.Version.String())
+		if err != nil {
+			return "", err
+		}
+		if key.Kind == CacheEntryModFile {
+			return filepath.Join(moduleDir, "@v", escapedVersion+".mod.json"), nil
+		}
+		return filepath.Join(moduleDir, "@v", escapedVersion+".json"), nil
+	case CacheEntryVersions:
+		return filepath.Join(moduleDir, "@v", "list.json"), nil
+	case CacheEntryLatest:
+		return filepath.Join(moduleDir, "@latest.json"), nil
+	default:
+		return "", errors.Errorf("unknown cache entry kind: %s", key.Kind)
+	}
+}
@@ -0,0 +1,281 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/semver"
+)
+
+// NewFileCacheBackend creates [FileCacheBackend] which stores the entries in the file at filePath.
+// The whole file is loaded into memory, entries written by other processes afterward are not visible.
+// Problems with the loaded entries are reported with [slog.Default].
+func NewFileCacheBackend(filePath string) (*FileCacheBackend, error) {
+	return newFileCacheBackend(filePath, slog.Default())
+}
+
+func newFileCacheBackend(filePath string, logger *slog.Logger) (*FileCacheBackend, error) {
+	persistence, err := newFilePersistence(filePath, logger)
+	if err != nil {
+		return nil, err
+	}
+	backend := &FileCacheBackend{
+		entries:     make(map[string]*CacheEntry),
+		persistence: persistence,
+		logger:      logger,
+	}
+	return backend, backend.loadFromPersistence()
+}
+
+// FileCacheBackend stores the cache entries in a JSON lines file.
+// Each entry is appended to the file, which can be shared by multiple processes.
+// The file can be managed with [CacheFile].
+type FileCacheBackend struct {
+	entries     map[string]*CacheEntry
+	rwm         sync.RWMutex
+	persistence cachePersistenceLayer
+	logger      *slog.Logger
+}
+
+type cachePersistenceLayer interface {
+	Save(module persistedModule) error
+	Load() ([]persistedModule, error)
+}
+
+func (f *FileCacheBackend) Get(key CacheKey) (*CacheEntry, bool, error) {
+	f.rwm.RLock()
+	defer f.rwm.RUnlock()
+	entry, found := f.entries[key.String()]
+	return entry, found, nil
+}
+
+func (f *FileCacheBackend) Put(entry *CacheEntry) error {
+	f.rwm.Lock()
+	defer f.rwm.Unlock()
+	f.entries[entry.Key().String()] = entry
+	return f.persistence.Save(newPersistedModule(entry))
+}
+
+// Cache entry kinds as persisted in the file,
+// specific versions' entries have no kind for backwards compatibility.
+const (
+	cacheKindVersions = string(CacheEntryVersions)
+	cacheKindLatest   = string(CacheEntryLatest)
+	cacheKindModFile  = string(CacheEntryModFile)
+)
+
+type persistedModule struct {
+	Kind    string          `json:"kind,omitempty"`
+	Path    string          `json:"path"`
+	Version *semver.Version `json:"version,omitempty"`
+	Time    time.Time       `json:"time"`
+	// Versions is only set for version list entries.
+	Versions []*semver.Version `json:"versions,omitempty"`
+	// ModFile and Sum are only set for go.mod file entries.
+	ModFile []byte `json:"mod_file,omitempty"`
+	Sum     string `json:"sum,omitempty"`
+	// FetchedAt is not set for entries created before fetch time was recorded.
+	FetchedAt *time.Time `json:"fetched_at,omitempty"`
+}
+
+func newPersistedModule(entry *CacheEntry) persistedModule {
+	m := persistedModule{
+		Path:      entry.Path,
+		Version:   entry.Version,
+		Time:      entry.Time,
+		Versions:  entry.Versions,
+		ModFile:   entry.ModFile,
+		Sum:       entry.Sum,
+		FetchedAt: &entry.FetchedAt,
+	}
+	if entry.Kind != CacheEntryModule {
+		m.Kind = string(entry.Kind)
+	}
+	return m
+}
+
+func (m persistedModule) cacheEntry() *CacheEntry {
+	kind := CacheEntryModule
+	if m.Kind != "" {
+		kind = CacheEntryKind(m.Kind)
+	}
+	return &CacheEntry{
+		Kind:      kind,
+		Path:      m.Path,
+		Version:   m.Version,
+		Time:      m.Time,
+		Versions:  m.Versions,
+		ModFile:   m.ModFile,
+		Sum:       m.Sum,
+		FetchedAt: derefTime(m.FetchedAt),
+	}
+}
+
+func (f *FileCacheBackend) loadFromPersistence() error {
+	f.rwm.Lock()
+	defer f.rwm.Unlock()
+	modules, err := f.persistence.Load()
+	if err != nil {
+		return err
+	}
+	for _, m := range modules {
+		entry := m.cacheEntry()
+		key := entry.Key().String()
+		// Version lists and latest versions are appended on refresh, the last entry wins.
+		if _, ok := f.entries[key]; ok && entry.Kind.isImmutable() {
+			f.logger.Warn("duplicate cache entry detected", "key", key)
+			continue
+		}
+		f.entries[key] = entry
+	}
+	return nil
+}
+
+func newFilePersistence(filePath string, logger *slog.Logger) (*filePersistence, error) {
+	// The function does an os.Stat under the hood anyway, so there's no gain in pre-checking this step.
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o750); err != nil {
+		return nil, err
+	}
+	f, err := openCacheFileForAppend(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &filePersistence{path: filePath, file: f, lock: newFileLock(filePath), logger: logger}, nil
+}
+
+// filePersistence stores cache entries as JSON lines.
+// The file may be shared by multiple processes, each entry is appended with a single write
+// while holding an exclusive inter-process lock, and the file is read while holding a shared one.
+type filePersistence struct {
+	path   string
+	file   *os.File
+	lock   fileLock
+	logger *slog.Logger
+}
+
+func (f *filePersistence) Save(module persistedModule) error {
+	data, err := json.Marshal(module)
+	if err != nil {
+		return err
+	}
+	unlock, err := f.lock.Lock()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = unlock() }()
+	if err = f.reopenIfReplaced(); err != nil {
+		return err
+	}
+	// If another process was interrupted in the middle of writing an entry,
+	// start a new line so that only the partially written entry is corrupted.
+	terminated, err := endsWithNewline(f.file)
+	if err != nil {
+		return err
+	}
+	if !terminated {
+		data = append([]byte{'\n'}, data...)
+	}
+	_, err = f.file.Write(append(data, '\n'))
+	return err
+}
+
+func (f *filePersistence) Load() ([]persistedModule, error) {
+	unlock, err := f.lock.RLock()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = unlock() }()
+	if err = f.reopenIfReplaced(); err != nil {
+		return nil, err
+	}
+	if _, err = f.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	modules, corrupted, err := readCacheEntries(f.file)
+	if err != nil {
+		return nil, err
+	}
+	if corrupted > 0 {
+		f.logger.Warn(fmt.Sprintf("skipped corrupted cache entries, run '%s cache compact' to remove them",
+			ProgramName), "count", corrupted, "path", f.path)
+	}
+	return modules, nil
+}
+
+// reopenIfReplaced reopens the cache file if it was replaced by another process,
+// for instance, when the cache was compacted.
+func (f *filePersistence) reopenIfReplaced() error {
+	current, err := f.file.Stat()
+	if err != nil {
+		return err
+	}
+	onDisk, err := os.Stat(f.path)
+	if err == nil && os.SameFile(current, onDisk) {
+		return nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	file, err := openCacheFileForAppend(f.path)
+	if err != nil {
+		return err
+	}
+	_ = f.file.Close()
+	f.file = file
+	return nil
+}
+
+func openCacheFileForAppend(filePath string) (*os.File, error) {
+	// #nosec G304
+	return os.OpenFile(filePath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+}
+
+func endsWithNewline(f *os.File) (bool, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	if info.Size() == 0 {
+		return true, nil
+	}
+	last := make([]byte, 1)
+	if _, err = f.ReadAt(last, info.Size()-1); err != nil {
+		return false, err
+	}
+	return last[0] == '\n', nil
+}
+
+// maxCacheEntrySize limits the size of a single cache entry, version lists of some modules are long.
+const maxCacheEntrySize = 16 * 1024 * 1024
+
+// readCacheEntries reads JSON lines cache entries.
+// Lines which cannot be decoded, for instance, due to interrupted writes, are skipped and counted.
+func readCacheEntries(r io.Reader) (modules []persistedModule, corrupted int, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxCacheEntrySize)
+	modules = make([]persistedModule, 0)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var m persistedModule
+		if err = json.Unmarshal(line, &m); err != nil || m.Path == "" {
+			corrupted++
+			continue
+		}
+		modules = append(modules, m)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, 0, err
+	}
+	return modules, corrupted, nil
+}
@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"container/list"
+	"sync"
+)
+
+// DefaultLRUCacheSize is the default maximum number of entries stored by [LRUCacheBackend].
+const DefaultLRUCacheSize = 10_000
+
+// NewLRUCacheBackend creates [LRUCacheBackend] which stores at most size entries.
+// If size is not positive, [DefaultLRUCacheSize] is used.
+func NewLRUCacheBackend(size int) *LRUCacheBackend {
+	if size <= 0 {
+		size = DefaultLRUCacheSize
+	}
+	return &LRUCacheBackend{
+		size:    size,
+		entries: make(map[string]*list.Element, size),
+		order:   list.New(),
+	}
+}
+
+// LRUCacheBackend stores the cache entries in memory.
+// Once the size limit is reached, the least recently used entries are evicted.
+type LRUCacheBackend struct {
+	size    int
+	entries map[string]*list.Element
+	// order holds *CacheEntry values, most recently used ones at the front.
+	order *list.List
+	mu    sync.Mutex
+}
+
+func (l *LRUCacheBackend) Get(key CacheKey) (*CacheEntry, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	element, found := l.entries[key.String()]
+	if !found {
+		return nil, false, nil
+	}
+	l.order.MoveToFront(element)
+	return element.Value.(*CacheEntry), true, nil
+}
+
+func (l *LRUCacheBackend) Put(entry *CacheEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := entry.Key().String()
+	if element, found := l.entries[key]; found {
+		element.Value = entry
+		l.order.MoveToFront(element)
+		return nil
+	}
+	l.entries[key] = l.order.PushFront(entry)
+	for l.order.Len() > l.size {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.entries, oldest.Value.(*CacheEntry).Key().String())
+	}
+	return nil
+}
+
+// Len returns the number of stored entries.
+func (l *LRUCacheBackend) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRUCacheBackend_Eviction(t *testing.T) {
+	backend := NewLRUCacheBackend(2)
+	entry := func(path string) *CacheEntry {
+		return &CacheEntry{Kind: CacheEntryVersions, Path: path}
+	}
+	require.NoError(t, backend.Put(entry("a")))
+	require.NoError(t, backend.Put(entry("b")))
+	// Mark 'a' as recently used.
+	_, _, _ = backend.Get(entry("a").Key())
+	require.NoError(t, backend.Put(entry("c")))
+
+	assert.Equal(t, 2, backend.Len())
+	for path, expected := range map[string]bool{"a": true, "b": false, "c": true} {
+		_, found, err := backend.Get(entry(path).Key())
+		require.NoError(t, err)
+		assert.Equal(t, expected, found, path)
+	}
+}
+
+func TestFileCacheBackend_Reload(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "modules")
+	backend, err := NewFileCacheBackend(filePath)
+	require.NoError(t, err)
+	entry := &CacheEntry{
+		Kind:     CacheEntryVersions,
+		Path:     "github.com/a/b",
+		Versions: []*semver.Version{semver.MustParse("v1.0.0")},
+	}
+	require.NoError(t, backend.Put(entry))
+
+	reloaded, err := NewFileCacheBackend(filePath)
+	require.NoError(t, err)
+	loaded, found, err := reloaded.Get(entry.Key())
+	require.NoError(t, err)
+	require.True(t, found)
+	assertCacheEntriesEqual(t, entry, loaded)
+}
+
+func assertCacheEntriesEqual(t *testing.T, expected, actual *CacheEntry) {
+	t.Helper()
+	assert.Equal(t, expected.Kind, actual.Kind)
+	assert.Equal(t, expected.Path, actual.Path)
+	assert.Equal(t, fmt.Sprint(expected.Version), fmt.Sprint(actual.Version))
+	assert.True(t, expected.Time.Equal(actual.Time), "time: expected %s, got %s", expected.Time, actual.Time)
+	assert.Equal(t, fmt.Sprint(expected.Versions), fmt.Sprint(actual.Versions))
+	assert.Equal(t, expected.ModFile, actual.ModFile)
+	assert.Equal(t, expected.Sum, actual.Sum)
+	assert.True(t, expected.FetchedAt.Equal(actual.FetchedAt),
+		"fetched at: expected %s, got %s", expected.FetchedAt, actual.FetchedAt)
+}
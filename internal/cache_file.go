@@ -0,0 +1,338 @@
+package internal
+
+import (
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+	"golang.org/x/mod/module"
+)
+
+// CacheFile provides management operations on the cache file.
+// Unlike [Cache], it operates on the raw entries, including duplicates and stale ones.
+type CacheFile struct {
+	Path string
+}
+
+// OpenCacheFile returns [CacheFile] for the given path, if empty, the default location is used.
+func OpenCacheFile(filePath string) (CacheFile, error) {
+	filePath, err := resolveCacheFilePath(filePath)
+	if err != nil {
+		return CacheFile{}, err
+	}
+	return CacheFile{Path: filePath}, nil
+}
+
+// CacheStats describes the contents of the cache file.
+type CacheStats struct {
+	// Entries is the total number of entries, including duplicates.
+	Entries int
+	// Modules is the number of unique module versions' entries.
+	Modules int
+	// VersionLists is the number of unique cached version lists.
+	VersionLists int
+	// Latest is the number of unique cached latest versions.
+	Latest int
+	// ModFiles is the number of unique cached go.mod files.
+	ModFiles int
+	// Duplicates is the number of entries which would be removed by [CacheFile.Compact].
+	Duplicates int
+	// Corrupted is the number of lines which could not be decoded, they are removed by [CacheFile.Compact].
+	Corrupted int
+	// Size is the size of the cache file in bytes.
+	Size int64
+	// LastRun is nil if no run statistics were recorded yet.
+	LastRun *CacheRunStats
+}
+
+// CacheRunStats describes cache usage during a single run.
+type CacheRunStats struct {
+	Hits   int64     `json:"hits"`
+	Misses int64     `json:"misses"`
+	Time   time.Time `json:"time"`
+}
+
+// HitRate returns the ratio of cache hits to all lookups.
+func (s CacheRunStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// CacheMismatch describes a cached entry which differs from the upstream information.
+type CacheMismatch struct {
+	Path     string
+	Version  *semver.Version
+	Cached   time.Time
+	Upstream time.Time
+	// Err is set if the upstream information could not be fetched.
+	Err error
+}
+
+// Stats reads the cache file and returns its statistics.
+func (f CacheFile) Stats() (*CacheStats, error) {
+	entries, corrupted, err := f.read()
+	if err != nil {
+		return nil, err
+	}
+	stats := &CacheStats{Entries: len(entries), Corrupted: corrupted}
+	compacted := compactCacheEntries(entries)
+	stats.Duplicates = len(entries) - len(compacted)
+	for _, entry := range compacted {
+		switch entry.Kind {
+		case cacheKindVersions:
+			stats.VersionLists++
+		case cacheKindLatest:
+			stats.Latest++
+		case cacheKindModFile:
+			stats.ModFiles++
+		default:
+			stats.Modules++
+		}
+	}
+	if info, err := os.Stat(f.Path); err == nil {
+		stats.Size = info.Size()
+	}
+	data, err := os.ReadFile(cacheStatsFilePath(f.Path))
+	switch {
+	case err == nil:
+		var runStats CacheRunStats
+		if err = json.Unmarshal(data, &runStats); err != nil {
+			return nil, errors.Wrap(err, "failed to decode cache run stats")
+		}
+		stats.LastRun = &runStats
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+	return stats, nil
+}
+
+// Prune removes the entries fetched before now minus olderThan and matching any of the module path patterns.
+// Patterns follow the GOPRIVATE syntax, see [module.MatchPrefixPatterns].
+// Zero olderThan or no patterns disable the respective criterion, but at least one must be provided.
+// Entries created before fetch time was recorded are considered older than any age.
+func (f CacheFile) Prune(olderThan time.Duration, patterns []string) (removed int, err error) {
+	if olderThan <= 0 && len(patterns) == 0 {
+		return 0, errors.New("either age or module patterns must be provided to prune the cache")
+	}
+	threshold := time.Now().Add(-olderThan)
+	globs := strings.Join(patterns, ",")
+	err = f.update(func(entries []persistedModule) ([]persistedModule, error) {
+		kept := make([]persistedModule, 0, len(entries))
+		for _, entry := range entries {
+			matchesAge := olderThan <= 0 || entry.FetchedAt == nil || entry.FetchedAt.Before(threshold)
+			matchesPath := globs == "" || module.MatchPrefixPatterns(globs, entry.Path)
+			if !matchesAge || !matchesPath {
+				kept = append(kept, entry)
+			}
+		}
+		removed = len(entries) - len(kept)
+		return kept, nil
+	})
+	return removed, err
+}
+
+// Compact rewrites the cache file without duplicated entries.
+// Corrupted lines are dropped as well, but they are not included in the removed count.
+func (f CacheFile) Compact() (removed int, err error) {
+	err = f.update(func(entries []persistedModule) ([]persistedModule, error) {
+		compacted := compactCacheEntries(entries)
+		removed = len(entries) - len(compacted)
+		return compacted, nil
+	})
+	return removed, err
+}
+
+// Export writes the compacted cache entries to w.
+// The output can be imported into another cache file with [CacheFile.Import].
+func (f CacheFile) Export(w io.Writer) error {
+	entries, _, err := f.read()
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	for _, entry := range compactCacheEntries(entries) {
+		if err = enc.Encode(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Import merges the entries read from r, produced by [CacheFile.Export], into the cache file.
+// For version lists and latest versions, the most recently fetched entry is kept.
+func (f CacheFile) Import(r io.Reader) (imported int, err error) {
+	importedEntries, corrupted, err := readCacheEntries(r)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to read imported cache entries")
+	}
+	if corrupted > 0 {
+		return 0, errors.Errorf("failed to decode %d imported cache entries", corrupted)
+	}
+	for i, entry := range importedEntries {
+		if err = validateCacheEntry(entry); err != nil {
+			return 0, errors.Wrapf(err, "invalid imported cache entry #%d", i+1)
+		}
+	}
+	err = f.update(func(entries []persistedModule) ([]persistedModule, error) {
+		return compactCacheEntries(append(entries, importedEntries...)), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(importedEntries), nil
+}
+
+// Verify re-fetches the information about a random sample of cached module versions
+// and compares it with the cached one. If sample is not positive, all entries are verified.
+// It returns the number of verified entries and the detected mismatches.
+func (f CacheFile) Verify(
+	getInfo func(path string, version *semver.Version) (*Module, error),
+	sample int,
+) (verified int, mismatches []CacheMismatch, err error) {
+	entries, _, err := f.read()
+	if err != nil {
+		return 0, nil, err
+	}
+	modules := make([]persistedModule, 0, len(entries))
+	for _, entry := range compactCacheEntries(entries) {
+		if entry.Kind == "" {
+			modules = append(modules, entry)
+		}
+	}
+	if sample > 0 && sample < len(modules) {
+		rand.Shuffle(len(modules), func(i, j int) { modules[i], modules[j] = modules[j], modules[i] })
+		modules = modules[:sample]
+	}
+	for _, entry := range modules {
+		upstream, err := getInfo(entry.Path, entry.Version)
+		switch {
+		case err != nil:
+			mismatches = append(mismatches, CacheMismatch{
+				Path:    entry.Path,
+				Version: entry.Version,
+				Cached:  entry.Time,
+				Err:     err,
+			})
+		case !upstream.Time.Equal(entry.Time):
+			mismatches = append(mismatches, CacheMismatch{
+				Path:     entry.Path,
+				Version:  entry.Version,
+				Cached:   entry.Time,
+				Upstream: upstream.Time,
+			})
+		}
+	}
+	return len(modules), mismatches, nil
+}
+
+func (f CacheFile) read() (entries []persistedModule, corrupted int, err error) {
+	unlock, err := newFileLock(f.Path).RLock()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() { _ = unlock() }()
+	return f.readUnlocked()
+}
+
+func (f CacheFile) readUnlocked() (entries []persistedModule, corrupted int, err error) {
+	// #nosec G304
+	file, err := os.Open(f.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, 0, nil
+		}
+		return nil, 0, err
+	}
+	defer func() { _ = file.Close() }()
+	return readCacheEntries(file)
+}
+
+// update replaces the cache file entries with the ones returned by modify.
+// The cache file is locked for the whole operation, so that no entries appended in the meantime are lost.
+func (f CacheFile) update(modify func(entries []persistedModule) ([]persistedModule, error)) error {
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0o750); err != nil {
+		return err
+	}
+	unlock, err := newFileLock(f.Path).Lock()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = unlock() }()
+	// Corrupted entries are dropped when the file is rewritten.
+	entries, _, err := f.readUnlocked()
+	if err != nil {
+		return err
+	}
+	if entries, err = modify(entries); err != nil {
+		return err
+	}
+	return writeFileAtomic(f.Path, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		for _, entry := range entries {
+			if err := enc.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// compactCacheEntries removes duplicated entries, preserving the order of the remaining ones.
+// The first entry of a module version is kept, as this information never changes.
+// For version lists and latest versions the most recently fetched entry is kept,
+// if fetch times are equal, the last one wins.
+func compactCacheEntries(entries []persistedModule) []persistedModule {
+	keyOf := func(entry persistedModule) string {
+		return entry.cacheEntry().Key().String()
+	}
+	selected := make(map[string]int, len(entries))
+	for i, entry := range entries {
+		k := keyOf(entry)
+		j, found := selected[k]
+		switch {
+		case !found:
+			selected[k] = i
+		case !entry.cacheEntry().Kind.isImmutable() &&
+			!derefTime(entry.FetchedAt).Before(derefTime(entries[j].FetchedAt)):
+			selected[k] = i
+		}
+	}
+	compacted := make([]persistedModule, 0, len(selected))
+	for i, entry := range entries {
+		if selected[keyOf(entry)] == i {
+			compacted = append(compacted, entry)
+		}
+	}
+	return compacted
+}
+
+func validateCacheEntry(entry persistedModule) error {
+	if entry.Path == "" {
+		return errors.New("module path is empty")
+	}
+	switch entry.Kind {
+	case "", cacheKindLatest:
+		if entry.Version == nil {
+			return errors.New("module version is empty")
+		}
+	case cacheKindModFile:
+		if entry.Version == nil {
+			return errors.New("module version is empty")
+		}
+		if sum, err := HashModFile(entry.ModFile); err != nil || sum != entry.Sum {
+			return errors.New("go.mod file does not match its hash")
+		}
+	case cacheKindVersions:
+	default:
+		return errors.Errorf("unknown entry kind: %s", entry.Kind)
+	}
+	return nil
+}
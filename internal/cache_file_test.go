@@ -0,0 +1,145 @@
+package internal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCacheFileContent = `{"path":"github.com/a/b","version":"1.0.0","time":"2023-01-01T00:00:00Z"}
+{"path":"github.com/a/b","version":"1.0.0","time":"2023-01-01T00:00:00Z"}
+{"kind":"versions","path":"github.com/a/b","time":"0001-01-01T00:00:00Z","versions":["1.0.0","1.1.0"],` +
+	`"fetched_at":"2024-02-01T00:00:00Z"}
+{"kind":"versions","path":"github.com/a/b","time":"0001-01-01T00:00:00Z","versions":["1.0.0"],` +
+	`"fetched_at":"2024-01-01T00:00:00Z"}
+{"path":"github.com/c/d","version":"2.0.0","time":"2023-06-01T00:00:00Z","fetched_at":"2999-01-01T00:00:00Z"}
+`
+
+func newTestCacheFile(t *testing.T) CacheFile {
+	t.Helper()
+	filePath := filepath.Join(t.TempDir(), "modules")
+	require.NoError(t, os.WriteFile(filePath, []byte(testCacheFileContent), 0o600))
+	return CacheFile{Path: filePath}
+}
+
+func TestCacheFile_Stats(t *testing.T) {
+	cacheFile := newTestCacheFile(t)
+	require.NoError(t, os.WriteFile(
+		cacheStatsFilePath(cacheFile.Path),
+		[]byte(`{"hits":3,"misses":1,"time":"2024-01-01T00:00:00Z"}`),
+		0o600))
+
+	stats, err := cacheFile.Stats()
+
+	require.NoError(t, err)
+	require.NotNil(t, stats.LastRun)
+	assert.Equal(t, 0.75, stats.LastRun.HitRate())
+	stats.LastRun = nil
+	assert.Equal(t, &CacheStats{
+		Entries:      5,
+		Modules:      2,
+		VersionLists: 1,
+		Duplicates:   2,
+		Size:         int64(len(testCacheFileContent)),
+	}, stats)
+}
+
+func TestCacheFile_Compact(t *testing.T) {
+	cacheFile := newTestCacheFile(t)
+
+	removed, err := cacheFile.Compact()
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+	entries, _, err := cacheFile.read()
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, cacheKindVersions, entries[1].Kind)
+	assert.Len(t, entries[1].Versions, 2, "most recently fetched version list must be kept")
+}
+
+func TestCacheFile_Prune(t *testing.T) {
+	tests := map[string]struct {
+		OlderThan time.Duration
+		Patterns  []string
+		Removed   int
+	}{
+		"age": {
+			OlderThan: time.Hour,
+			Removed:   4,
+		},
+		"pattern": {
+			Patterns: []string{"github.com/c/*"},
+			Removed:  1,
+		},
+		"age and pattern": {
+			OlderThan: time.Hour,
+			Patterns:  []string{"github.com/c"},
+			Removed:   0,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cacheFile := newTestCacheFile(t)
+
+			removed, err := cacheFile.Prune(test.OlderThan, test.Patterns)
+
+			require.NoError(t, err)
+			assert.Equal(t, test.Removed, removed)
+			entries, _, err := cacheFile.read()
+			require.NoError(t, err)
+			assert.Len(t, entries, 5-test.Removed)
+		})
+	}
+
+	_, err := newTestCacheFile(t).Prune(0, nil)
+	assert.Error(t, err)
+}
+
+func TestCacheFile_ExportImport(t *testing.T) {
+	source := newTestCacheFile(t)
+	var exported bytes.Buffer
+	require.NoError(t, source.Export(&exported))
+
+	target := CacheFile{Path: filepath.Join(t.TempDir(), "cache", "modules")}
+	imported, err := target.Import(&exported)
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, imported)
+	stats, err := target.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.Entries)
+
+	_, err = target.Import(strings.NewReader(`{"path":"github.com/a/b","time":"2023-01-01T00:00:00Z"}`))
+	assert.EqualError(t, err, "invalid imported cache entry #1: module version is empty")
+}
+
+func TestCacheFile_Verify(t *testing.T) {
+	cacheFile := newTestCacheFile(t)
+	getInfo := func(path string, version *semver.Version) (*Module, error) {
+		if path == "github.com/c/d" {
+			return nil, errors.New("not found")
+		}
+		return &Module{Path: path, Version: version, Time: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)}, nil
+	}
+
+	verified, mismatches, err := cacheFile.Verify(getInfo, 0)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, verified)
+	require.Len(t, mismatches, 2)
+	assert.Equal(t, "2023-01-02", mismatches[0].Upstream.Format(time.DateOnly))
+	assert.EqualError(t, mismatches[1].Err, "not found")
+
+	verified, _, err = cacheFile.Verify(getInfo, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, verified)
+}
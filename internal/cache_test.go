@@ -0,0 +1,202 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_TTL(t *testing.T) {
+	path := "github.com/a/b"
+	versions := []*semver.Version{semver.MustParse("v1.0.0"), semver.MustParse("v1.1.0")}
+	latest := &Module{Path: path, Version: semver.MustParse("v1.1.0"), Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	cache, err := NewCache(CacheConfig{FilePath: filepath.Join(t.TempDir(), "modules"), TTL: time.Hour})
+	require.NoError(t, err)
+	cache.now = func() time.Time { return now }
+
+	require.NoError(t, cache.SaveVersions(path, versions))
+	require.NoError(t, cache.SaveLatest(latest))
+
+	now = now.Add(time.Hour)
+	loadedVersions, loaded := cache.LoadVersions(path)
+	require.True(t, loaded)
+	assert.Equal(t, versions, loadedVersions)
+	loadedLatest, loaded := cache.LoadLatest(path)
+	require.True(t, loaded)
+	assert.Equal(t, latest, loadedLatest)
+
+	now = now.Add(time.Second)
+	_, loaded = cache.LoadVersions(path)
+	assert.False(t, loaded)
+	_, loaded = cache.LoadLatest(path)
+	assert.False(t, loaded)
+}
+
+func TestCache_RefreshAndBypass(t *testing.T) {
+	path := "github.com/a/b"
+	versions := []*semver.Version{semver.MustParse("v1.0.0")}
+	filePath := filepath.Join(t.TempDir(), "modules")
+
+	cache, err := NewCache(CacheConfig{FilePath: filePath})
+	require.NoError(t, err)
+	require.NoError(t, cache.SaveVersions(path, versions))
+
+	t.Run("refresh", func(t *testing.T) {
+		cache, err := NewCache(CacheConfig{FilePath: filePath, Refresh: true})
+		require.NoError(t, err)
+		_, loaded := cache.LoadVersions(path)
+		assert.False(t, loaded)
+	})
+	t.Run("bypass", func(t *testing.T) {
+		cache, err := NewCache(CacheConfig{FilePath: filePath, Bypass: true})
+		require.NoError(t, err)
+		_, loaded := cache.LoadVersions(path)
+		assert.False(t, loaded)
+		require.NoError(t, cache.SaveVersions("github.com/c/d", versions))
+	})
+
+	cache, err = NewCache(CacheConfig{FilePath: filePath})
+	require.NoError(t, err)
+	_, loaded := cache.LoadVersions(path)
+	assert.True(t, loaded)
+	_, loaded = cache.LoadVersions("github.com/c/d")
+	assert.False(t, loaded, "bypassed versions must not be persisted")
+}
+
+func TestCache_LoadFromPersistence(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "modules")
+	fetchedAt := time.Now().UTC().Format(time.RFC3339)
+	// Legacy entries have neither kind nor fetch time.
+	content := `{"path":"github.com/a/b","version":"1.0.0","time":"2023-01-01T00:00:00Z"}
+{"kind":"versions","path":"github.com/a/b","time":"0001-01-01T00:00:00Z","versions":["1.0.0"],` +
+		`"fetched_at":"2000-01-01T00:00:00Z"}
+{"kind":"versions","path":"github.com/a/b","time":"0001-01-01T00:00:00Z","versions":["1.0.0","1.1.0"],"fetched_at":"` +
+		fetchedAt + `"}
+{"kind":"latest","path":"github.com/a/b","version":"1.1.0","time":"2024-01-01T00:00:00Z","fetched_at":"` +
+		fetchedAt + `"}
+`
+	require.NoError(t, os.WriteFile(filePath, []byte(content), 0o600))
+
+	cache, err := NewCache(CacheConfig{FilePath: filePath})
+	require.NoError(t, err)
+
+	module, loaded := cache.Load("github.com/a/b", semver.MustParse("v1.0.0"))
+	require.True(t, loaded)
+	assert.Equal(t, "2023-01-01", module.Time.Format(time.DateOnly))
+	versions, loaded := cache.LoadVersions("github.com/a/b")
+	require.True(t, loaded)
+	assert.Len(t, versions, 2)
+	latest, loaded := cache.LoadLatest("github.com/a/b")
+	require.True(t, loaded)
+	assert.Equal(t, "1.1.0", latest.Version.String())
+	_, loaded = cache.Load("github.com/a/b", semver.MustParse("v1.1.0"))
+	assert.False(t, loaded, "latest entries are not mixed with versions' information")
+}
+
+func TestCache_ConcurrentInstances(t *testing.T) {
+	// Each cache opens its own file descriptor, which is equivalent to separate processes sharing the file.
+	filePath := filepath.Join(t.TempDir(), "modules")
+	const instances, entries = 8, 100
+	var wg sync.WaitGroup
+	for i := range instances {
+		cache, err := NewCache(CacheConfig{FilePath: filePath})
+		require.NoError(t, err)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range entries {
+				assert.NoError(t, cache.Save(&Module{
+					Path:    fmt.Sprintf("github.com/instance/m%d", i),
+					Version: semver.MustParse(fmt.Sprintf("v1.0.%d", j)),
+					Time:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				}))
+			}
+		}()
+	}
+	wg.Wait()
+
+	entriesRead, corrupted, err := CacheFile{Path: filePath}.read()
+	require.NoError(t, err)
+	assert.Zero(t, corrupted)
+	assert.Len(t, entriesRead, instances*entries)
+}
+
+func TestCache_CorruptedEntries(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "modules")
+	// The last entry was interrupted in the middle of writing.
+	content := `{"path":"github.com/a/b","version":"1.0.0","time":"2023-01-01T00:00:00Z"}
+{"path":"github.com/a/b","vers{"path":"github.com/c/d","version":"1.0.0","time":"2023-01-01T00:00:00Z"}
+not a JSON
+{"path":"github.com/e/f","version":"1.0.0","time":"2023-01-01T00:00:00Z"}
+{"path":"github.com/g/h","version":"1.0.0","ti`
+	require.NoError(t, os.WriteFile(filePath, []byte(content), 0o600))
+
+	cache, err := NewCache(CacheConfig{FilePath: filePath})
+	require.NoError(t, err)
+	_, loaded := cache.Load("github.com/a/b", semver.MustParse("v1.0.0"))
+	assert.True(t, loaded)
+	_, loaded = cache.Load("github.com/e/f", semver.MustParse("v1.0.0"))
+	assert.True(t, loaded)
+
+	require.NoError(t, cache.Save(&Module{Path: "github.com/i/j", Version: semver.MustParse("v1.0.0")}))
+	data, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"ti`+"\n"+`{"path":"github.com/i/j"`, "new entry must start in a new line")
+
+	stats, err := CacheFile{Path: filePath}.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.Entries)
+	assert.Equal(t, 3, stats.Corrupted)
+}
+
+func TestCache_Backend(t *testing.T) {
+	backend := NewLRUCacheBackend(0)
+	cache, err := NewCache(CacheConfig{Backend: backend})
+	require.NoError(t, err)
+	module := &Module{Path: "github.com/a/b", Version: semver.MustParse("v1.0.0")}
+
+	require.NoError(t, cache.Save(module))
+	require.NoError(t, cache.SaveLatest(module))
+
+	assert.Equal(t, 2, backend.Len())
+	_, loaded := cache.Load(module.Path, module.Version)
+	assert.True(t, loaded)
+	require.NoError(t, cache.SaveRunStats(), "run stats are not recorded for custom backends")
+}
+
+func TestCache_ModFile(t *testing.T) {
+	goSum, err := ReadGoSum([]byte(testGoSum))
+	require.NoError(t, err)
+	path, version := "github.com/pkg/errors", semver.MustParse("v0.9.1")
+	backend := NewLRUCacheBackend(0)
+	cache, err := NewCache(CacheConfig{Backend: backend, GoSum: goSum})
+	require.NoError(t, err)
+
+	t.Run("go.sum mismatch is not cached", func(t *testing.T) {
+		require.NoError(t, cache.SaveModFile(path, version, []byte("module github.com/evil/errors\n")))
+		assert.Zero(t, backend.Len())
+	})
+	t.Run("verified go.mod is cached", func(t *testing.T) {
+		require.NoError(t, cache.SaveModFile(path, version, []byte("module github.com/pkg/errors\n")))
+		data, loaded := cache.LoadModFile(path, version)
+		require.True(t, loaded)
+		assert.Equal(t, "module github.com/pkg/errors\n", string(data))
+	})
+	t.Run("tampered entry is ignored", func(t *testing.T) {
+		entry, found, err := backend.Get(CacheKey{Kind: CacheEntryModFile, Path: path, Version: version})
+		require.NoError(t, err)
+		require.True(t, found)
+		entry.ModFile = []byte("module github.com/evil/errors\n")
+		_, loaded := cache.LoadModFile(path, version)
+		assert.False(t, loaded)
+	})
+}
@@ -8,8 +8,15 @@ import (
 )
 
 func execCmd(name string, arg ...string) (*bytes.Buffer, error) {
+	return execCmdInDir("", name, arg...)
+}
+
+// execCmdInDir executes the command in the given working directory.
+// If dir is empty, current working directory is used.
+func execCmdInDir(dir, name string, arg ...string) (*bytes.Buffer, error) {
 	// #nosec G204
 	cmd := exec.Command(name, arg...)
+	cmd.Dir = dir
 	if cmd.Stdout != nil {
 		return nil, errors.New("exec: Stdout already set")
 	}
@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"slices"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// concurrencyLimiter limits the number of concurrent operations, nil limiter does not limit them.
+type concurrencyLimiter chan struct{}
+
+// newConcurrencyLimiter returns nil limiter if limit is not positive.
+func newConcurrencyLimiter(limit int) concurrencyLimiter {
+	if limit <= 0 {
+		return nil
+	}
+	return make(concurrencyLimiter, limit)
+}
+
+func (l concurrencyLimiter) acquire() {
+	if l != nil {
+		l <- struct{}{}
+	}
+}
+
+func (l concurrencyLimiter) release() {
+	if l != nil {
+		<-l
+	}
+}
+
+// doShared executes fn once for all concurrent calls with the same key.
+// Callers which shared the result receive its copy, so that they can safely modify it.
+func doShared[T any](group *singleflight.Group, key string, fn func() ([]T, error)) ([]T, error) {
+	v, err, shared := group.Do(key, func() (any, error) { return fn() })
+	if err != nil {
+		return nil, err
+	}
+	result := v.([]T)
+	if shared {
+		result = slices.Clone(result)
+	}
+	return result, nil
+}
@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/singleflight"
+)
+
+func TestGoProxyClient_Concurrency(t *testing.T) {
+	var requests, inFlight, maxInFlight atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			observed := maxInFlight.Load()
+			if current <= observed || maxInFlight.CompareAndSwap(observed, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write([]byte("v1.0.0\nv1.1.0\n"))
+	}))
+	defer srv.Close()
+	t.Setenv("GOPROXY", srv.URL)
+	client, err := NewGoProxyClient(nil, false, CacheConfig{})
+	require.NoError(t, err)
+	client.SetConcurrencyLimit(2)
+
+	var wg sync.WaitGroup
+	for _, path := range []string{"github.com/a/a", "github.com/b/b", "github.com/c/c", "github.com/d/d"} {
+		for range 3 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				versions, err := client.GetVersions(path)
+				if assert.NoError(t, err) {
+					assert.Len(t, versions, 2)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	// Concurrent requests for the same path are sent once.
+	assert.Equal(t, int32(4), requests.Load())
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(2))
+}
+
+func TestDoShared(t *testing.T) {
+	var (
+		calls   atomic.Int32
+		started = make(chan struct{})
+		finish  = make(chan struct{})
+		group   singleflight.Group
+		results = make([][]int, 2)
+		wg      sync.WaitGroup
+	)
+	fn := func() ([]int, error) {
+		if calls.Add(1) == 1 {
+			close(started)
+		}
+		<-finish
+		return []int{1, 2}, nil
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[0], _ = doShared(&group, "key", fn)
+	}()
+	<-started
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[1], _ = doShared(&group, "key", fn)
+	}()
+	// Give the second call a chance to join the in-flight one.
+	time.Sleep(10 * time.Millisecond)
+	close(finish)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, results[0], results[1])
+	results[0][0] = 3
+	assert.Equal(t, 1, results[1][0], "shared results must not alias")
+}
@@ -6,22 +6,28 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
-	"time"
 
 	"github.com/Masterminds/semver"
 	"github.com/pkg/errors"
+	"golang.org/x/sync/singleflight"
 )
 
-func NewDepsDevClient() *DepsDevClient {
+// NewDepsDevClient creates a client for deps.dev API, if client is nil, [DefaultHTTPConfig] is used.
+func NewDepsDevClient(client *http.Client) *DepsDevClient {
 	return &DepsDevClient{
-		http:   &http.Client{Timeout: 10 * time.Second},
+		http:   httpClientOrDefault(client),
 		apiURL: url.URL{Scheme: "https", Host: "api.deps.dev"},
 	}
 }
 
 type DepsDevClient struct {
-	http   *http.Client
-	apiURL url.URL
+	http     *http.Client
+	apiURL   url.URL
+	inflight singleflight.Group
+}
+
+func (d *DepsDevClient) String() string {
+	return "deps.dev"
 }
 
 // goSemverRegex allows us to filter out non-canonical semver versions.
@@ -29,7 +35,12 @@ type DepsDevClient struct {
 // Ref: https://github.com/nieomylnieja/go-libyear/issues/14.
 var goSemverRegex = regexp.MustCompile(`^v(\d+)\.(\d+)\.(\d+)`)
 
-func (c DepsDevClient) GetVersions(path string) ([]*semver.Version, error) {
+// GetVersions lists the module's versions, concurrent calls for the same path share the response.
+func (c *DepsDevClient) GetVersions(path string) ([]*semver.Version, error) {
+	return doShared(&c.inflight, path, func() ([]*semver.Version, error) { return c.getVersions(path) })
+}
+
+func (c *DepsDevClient) getVersions(path string) ([]*semver.Version, error) {
 	path = url.PathEscape(path)
 	resp, err := c.http.Get(c.apiURL.JoinPath("v3alpha/systems/go/packages", path).String())
 	if err != nil {
@@ -0,0 +1,60 @@
+//go:build !unix
+
+package internal
+
+import (
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	fileLockTimeout  = 30 * time.Second
+	fileLockStaleAge = time.Minute
+)
+
+// fileLock is an inter-process lock guarding the cache file.
+// On platforms without flock, the lock is held by exclusively creating a lock file,
+// both shared and exclusive locks are exclusive.
+type fileLock struct {
+	path string
+}
+
+func newFileLock(filePath string) fileLock {
+	return fileLock{path: filePath + ".lock"}
+}
+
+// Lock acquires an exclusive lock, blocking until it's available.
+func (l fileLock) Lock() (unlock func() error, err error) {
+	return l.lock()
+}
+
+// RLock acquires a shared lock, blocking until it's available.
+func (l fileLock) RLock() (unlock func() error, err error) {
+	return l.lock()
+}
+
+func (l fileLock) lock() (func() error, error) {
+	deadline := time.Now().Add(fileLockTimeout)
+	for {
+		// #nosec G304
+		f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err == nil {
+			_ = f.Close()
+			return func() error { return os.Remove(l.path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		// Lock file left by an interrupted process.
+		if info, statErr := os.Stat(l.path); statErr == nil && time.Since(info.ModTime()) > fileLockStaleAge {
+			_ = os.Remove(l.path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.Errorf("timed out waiting for %s lock", l.path)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
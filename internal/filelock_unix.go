@@ -0,0 +1,50 @@
+//go:build unix
+
+package internal
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// fileLock is an inter-process lock guarding the cache file.
+// It is backed by a separate lock file, so that it remains valid when the cache file is replaced.
+type fileLock struct {
+	path string
+}
+
+func newFileLock(filePath string) fileLock {
+	return fileLock{path: filePath + ".lock"}
+}
+
+// Lock acquires an exclusive lock, blocking until it's available.
+func (l fileLock) Lock() (unlock func() error, err error) {
+	return l.lock(syscall.LOCK_EX)
+}
+
+// RLock acquires a shared lock, blocking until it's available.
+func (l fileLock) RLock() (unlock func() error, err error) {
+	return l.lock(syscall.LOCK_SH)
+}
+
+func (l fileLock) lock(how int) (func() error, error) {
+	// #nosec G304
+	f, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		err = syscall.Flock(int(f.Fd()), how)
+		if err != syscall.EINTR {
+			break
+		}
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, errors.Wrapf(err, "failed to lock %s", l.path)
+	}
+	// Closing the file releases the lock.
+	return f.Close, nil
+}
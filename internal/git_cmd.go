@@ -15,11 +15,24 @@ func (g GitCmd) Clone(url, path string) error {
 	return err
 }
 
+// CloneBare creates a bare, blobless clone of the repository.
+// Commits and trees are downloaded eagerly, blobs are fetched on demand.
+func (g GitCmd) CloneBare(url, path string) error {
+	_, err := execCmd("git", "clone", "--bare", "--filter=blob:none", "--", url, path)
+	return err
+}
+
 func (g GitCmd) Pull(path string) error {
 	_, err := execCmd("git", "-C", path, "pull", "--ff-only")
 	return err
 }
 
+// FetchTags updates all tags of a bare clone from its origin.
+func (g GitCmd) FetchTags(path string) error {
+	_, err := execCmd("git", "-C", path, "fetch", "--force", "--prune", "origin", "+refs/tags/*:refs/tags/*")
+	return err
+}
+
 func (g GitCmd) ListTags(path string) (io.Reader, error) {
 	return execCmd(
 		"git", "-C", path,
@@ -29,11 +42,31 @@ func (g GitCmd) ListTags(path string) (io.Reader, error) {
 		"refs/tags")
 }
 
+// ListRemoteTags lists tags of a remote repository without cloning it.
+func (g GitCmd) ListRemoteTags(url string) (io.Reader, error) {
+	return execCmd("git", "ls-remote", "--tags", "--refs", "--", url)
+}
+
+// GetLatestCommit reports the hash and commit date of the given revision
+// in '<hash> <ISO 8601 date>' format.
+func (g GitCmd) GetLatestCommit(path, rev string) (io.Reader, error) {
+	return execCmd("git", "-C", path, "log", "-1", "--format=%H %cI", rev)
+}
+
 func (g GitCmd) Checkout(path, tag string) error {
 	_, err := execCmd("git", "-C", path, "checkout", tag)
 	return err
 }
 
+// ShowFile reads the contents of a file at the given revision.
+func (g GitCmd) ShowFile(path, rev, file string) ([]byte, error) {
+	buf, err := execCmd("git", "-C", path, "show", rev+":"+file)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 var gitHeadBranchRegexp = regexp.MustCompile(`(?m)^\s*origin/HEAD\s*->\s*origin/(?P<branch>.*)\s*$`)
 
 func (g GitCmd) GetHeadBranchName(path string) (string, error) {
@@ -65,3 +98,69 @@ func getHeadBranchName(reader io.Reader) (string, error) {
 	}
 	return branch, err
 }
+
+// limitedGitCmd limits the number of concurrently running git subprocesses.
+type limitedGitCmd struct {
+	GitCmdI
+	limiter concurrencyLimiter
+}
+
+func (g *limitedGitCmd) Clone(url, path string) error {
+	g.limiter.acquire()
+	defer g.limiter.release()
+	return g.GitCmdI.Clone(url, path)
+}
+
+func (g *limitedGitCmd) CloneBare(url, path string) error {
+	g.limiter.acquire()
+	defer g.limiter.release()
+	return g.GitCmdI.CloneBare(url, path)
+}
+
+func (g *limitedGitCmd) Pull(path string) error {
+	g.limiter.acquire()
+	defer g.limiter.release()
+	return g.GitCmdI.Pull(path)
+}
+
+func (g *limitedGitCmd) FetchTags(path string) error {
+	g.limiter.acquire()
+	defer g.limiter.release()
+	return g.GitCmdI.FetchTags(path)
+}
+
+func (g *limitedGitCmd) ListTags(path string) (io.Reader, error) {
+	g.limiter.acquire()
+	defer g.limiter.release()
+	return g.GitCmdI.ListTags(path)
+}
+
+func (g *limitedGitCmd) ListRemoteTags(url string) (io.Reader, error) {
+	g.limiter.acquire()
+	defer g.limiter.release()
+	return g.GitCmdI.ListRemoteTags(url)
+}
+
+func (g *limitedGitCmd) Checkout(path, tag string) error {
+	g.limiter.acquire()
+	defer g.limiter.release()
+	return g.GitCmdI.Checkout(path, tag)
+}
+
+func (g *limitedGitCmd) GetLatestCommit(path, rev string) (io.Reader, error) {
+	g.limiter.acquire()
+	defer g.limiter.release()
+	return g.GitCmdI.GetLatestCommit(path, rev)
+}
+
+func (g *limitedGitCmd) ShowFile(path, rev, file string) ([]byte, error) {
+	g.limiter.acquire()
+	defer g.limiter.release()
+	return g.GitCmdI.ShowFile(path, rev, file)
+}
+
+func (g *limitedGitCmd) GetHeadBranchName(path string) (string, error) {
+	g.limiter.acquire()
+	defer g.limiter.release()
+	return g.GitCmdI.GetHeadBranchName(path)
+}
@@ -2,27 +2,36 @@ package internal
 
 import (
 	"bufio"
-	"fmt"
 	"io"
 	"os"
+	pathlib "path"
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/Masterminds/semver"
 	"github.com/pkg/errors"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/sync/singleflight"
 )
 
 //go:generate mockgen -destination mocks/git.go -package mocks -typed . GitCmdI
 
 type GitCmdI interface {
 	Clone(url, path string) error
+	CloneBare(url, path string) error
 	Pull(path string) error
+	FetchTags(path string) error
 	ListTags(path string) (io.Reader, error)
+	ListRemoteTags(url string) (io.Reader, error)
 	Checkout(path, tag string) error
+	GetLatestCommit(path, rev string) (io.Reader, error)
+	ShowFile(path, rev, file string) ([]byte, error)
 	GetHeadBranchName(path string) (string, error)
 }
 
@@ -34,12 +43,38 @@ func NewGitVCS(cacheDir string, git GitCmdI) *GitHandler {
 	}
 }
 
+// NewLightweightGitVCS creates a [GitHandler] which does not clone whole repositories.
+// Versions are resolved with 'git ls-remote' and a bare, blobless clone is only
+// created once commit dates or go.mod contents are needed.
+func NewLightweightGitVCS(cacheDir string, git GitCmdI) *GitHandler {
+	handler := NewGitVCS(cacheDir, git)
+	handler.lightweight = true
+	return handler
+}
+
 // GitHandler is a module handler for git version control system.
 type GitHandler struct {
-	git        GitCmdI
-	cacheDir   string
-	pathToRepo map[string]*gitRepo
-	mu         sync.RWMutex
+	git         GitCmdI
+	cacheDir    string
+	lightweight bool
+	pathToRepo  map[string]*gitRepo
+	mu          sync.RWMutex
+	inflight    singleflight.Group
+}
+
+// SetConcurrencyLimit limits the number of concurrently running git subprocesses, zero means no limit.
+// It must be called before the handler is used.
+func (g *GitHandler) SetConcurrencyLimit(limit int) {
+	if limit > 0 {
+		g.git = &limitedGitCmd{GitCmdI: g.git, limiter: newConcurrencyLimiter(limit)}
+	}
+}
+
+func (g *GitHandler) String() string {
+	if g.lightweight {
+		return "git (lightweight)"
+	}
+	return "git"
 }
 
 // gitRepo is not concurrently safe.
@@ -49,12 +84,22 @@ type GitHandler struct {
 type gitRepo struct {
 	URL     string
 	DirPath string
-	tags    []gitTag
+	// Subdir is the module's directory relative to the repository root.
+	// Tags of modules living in a subdirectory are prefixed with it, e.g. 'sub/module/v1.2.3'.
+	Subdir string
+	// PathMajor is the major version suffix of the module path, e.g. '/v2'.
+	PathMajor string
+	tags      []gitTag
+	// synced is only used in lightweight mode and reports
+	// whether the bare clone was created or updated.
+	synced bool
 }
 
 type gitTag struct {
 	Version *semver.Version
 	Date    time.Time
+	// Ref is the full tag name, including the subdirectory prefix.
+	Ref string
 }
 
 var githubRegexp = regexp.MustCompile(`^(?P<root>github\.com/[\w.\-]+/[\w.\-]+)(/[\w.\-]+)*$`)
@@ -75,12 +120,18 @@ func (g *GitHandler) CanHandle(path string) (bool, error) {
 	}
 	g.mu.Lock()
 	defer g.mu.Unlock()
+	prefix, pathMajor, _ := module.SplitPathVersion(path)
 	repo := &gitRepo{
-		URL:     "https://" + root + ".git",
-		DirPath: filepath.Join(g.cacheDir, path),
+		URL:       "https://" + root + ".git",
+		DirPath:   filepath.Join(g.cacheDir, path),
+		Subdir:    strings.TrimPrefix(strings.TrimPrefix(prefix, root), "/"),
+		PathMajor: pathMajor,
 	}
-	if err := g.initializeRepo(path, repo); err != nil {
-		return false, err
+	// In lightweight mode the repository is lazily cloned when needed.
+	if !g.lightweight {
+		if err := g.initializeRepo(path, repo); err != nil {
+			return false, err
+		}
 	}
 	g.pathToRepo[path] = repo
 	return true, nil
@@ -90,9 +141,22 @@ func (g *GitHandler) Name() string {
 	return "git"
 }
 
+// GetVersions lists the module's versions, concurrent calls for the same path share the result.
 func (g *GitHandler) GetVersions(path string) ([]*semver.Version, error) {
-	repo := g.getRepoForPath(path)
-	tags, err := g.listAllTags(repo)
+	return doShared(&g.inflight, path, func() ([]*semver.Version, error) { return g.getVersions(path) })
+}
+
+func (g *GitHandler) getVersions(path string) ([]*semver.Version, error) {
+	repo, err := g.lookupRepo(path)
+	if err != nil {
+		return nil, err
+	}
+	var tags []gitTag
+	if g.lightweight {
+		tags, err = g.listRemoteTags(repo)
+	} else {
+		tags, err = g.listAllTags(repo)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -103,65 +167,71 @@ func (g *GitHandler) GetVersions(path string) ([]*semver.Version, error) {
 	return versions, nil
 }
 
+// GetModFile reads the go.mod file of the module at the given version.
+// The file is located by the module's directory rather than by searching the whole repository.
+// If the module path has a major version suffix, major subdirectory layout (e.g. 'sub/v2/go.mod')
+// takes precedence over major branch layout (e.g. 'sub/go.mod').
 func (g *GitHandler) GetModFile(path string, version *semver.Version) ([]byte, error) {
-	moduleNameRegexp := regexp.MustCompile(fmt.Sprintf(`(?m)^module %s$`, path))
-	repo := g.getRepoForPath(path)
-	if err := g.git.Checkout(repo.DirPath, version.Original()); err != nil {
-		return nil, errors.Wrapf(err, "failed to checkout version %s of %s", version.Original(), path)
+	repo, err := g.lookupRepo(path)
+	if err != nil {
+		return nil, err
 	}
-	var goMod []byte
-	if err := filepath.Walk(repo.DirPath, func(walkPath string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if info.IsDir() && info.Name() == "vendor" {
-			return filepath.SkipDir
+	tag, err := g.findTag(repo, version)
+	if err != nil {
+		return nil, err
+	}
+	if !g.lightweight {
+		if err = g.git.Checkout(repo.DirPath, tag.Ref); err != nil {
+			return nil, errors.Wrapf(err, "failed to checkout version %s of %s", tag.Ref, path)
 		}
-		if info.Name() != "go.mod" {
-			return nil
+	}
+	for _, file := range repo.goModCandidates() {
+		var data []byte
+		if g.lightweight {
+			data, err = g.git.ShowFile(repo.DirPath, tag.Ref, file)
+		} else {
+			// #nosec G304
+			data, err = os.ReadFile(filepath.Join(repo.DirPath, filepath.FromSlash(file)))
 		}
-		// #nosec G304
-		data, err := os.ReadFile(walkPath)
 		if err != nil {
-			return err
+			continue
 		}
-		if moduleNameRegexp.Match(data) {
-			goMod = data
+		if modfile.ModulePath(data) == path {
+			return data, nil
 		}
-		return nil
-	}); err != nil {
-		return nil, err
-	}
-	if len(goMod) == 0 {
-		return nil, errors.Errorf("no go.mod file found for %s module", path)
 	}
-	return goMod, nil
+	return nil, errors.Errorf("no go.mod file found for %s module at %s tag", path, tag.Ref)
 }
 
 func (g *GitHandler) GetInfo(path string, version *semver.Version) (*Module, error) {
-	repo := g.getRepoForPath(path)
-	tags, err := g.listAllTags(repo)
+	repo, err := g.lookupRepo(path)
 	if err != nil {
 		return nil, err
 	}
-	for _, tag := range tags {
-		if tag.Version.String() == version.String() {
-			return &Module{
-				Path:    path,
-				Version: tag.Version,
-				Time:    tag.Date,
-			}, nil
-		}
+	tag, err := g.findTag(repo, version)
+	if err != nil {
+		return nil, err
 	}
-	return nil, errors.Errorf("%s version not found for %s path", version, path)
+	return &Module{
+		Path:    path,
+		Version: tag.Version,
+		Time:    tag.Date,
+	}, nil
 }
 
 func (g *GitHandler) GetLatestInfo(path string) (*Module, error) {
-	repo := g.getRepoForPath(path)
+	repo, err := g.lookupRepo(path)
+	if err != nil {
+		return nil, err
+	}
 	tags, err := g.listAllTags(repo)
 	if err != nil {
 		return nil, err
 	}
+	// Repositories which were never tagged are referenced with pseudo-versions.
+	if len(tags) == 0 {
+		return g.getLatestCommitInfo(path, repo)
+	}
 	latestTag := tags[len(tags)-1]
 	return &Module{
 		Path:    path,
@@ -170,12 +240,58 @@ func (g *GitHandler) GetLatestInfo(path string) (*Module, error) {
 	}, nil
 }
 
+// getLatestCommitInfo creates a pseudo-version for the latest commit on the default branch.
+func (g *GitHandler) getLatestCommitInfo(path string, repo *gitRepo) (*Module, error) {
+	// Full clone might have any revision checked out, bare clone's HEAD always points to the default branch.
+	rev := "origin/HEAD"
+	if g.lightweight {
+		rev = "HEAD"
+	}
+	commitReader, err := g.git.GetLatestCommit(repo.DirPath, rev)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(commitReader)
+	if err != nil {
+		return nil, err
+	}
+	line := strings.TrimSpace(string(data))
+	hash, rawDate, found := strings.Cut(line, " ")
+	if !found {
+		return nil, errors.Errorf("unexpected 'git log' output: %s, expected: '<hash> <date>'", line)
+	}
+	date, err := time.Parse(time.RFC3339, rawDate)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse commit date: %s", line)
+	}
+	version, err := NewPseudoVersion(repo.PathMajor, date, hash)
+	if err != nil {
+		return nil, err
+	}
+	return &Module{
+		Path:    path,
+		Version: version,
+		Time:    date,
+	}, nil
+}
+
 func (g *GitHandler) getRepoForPath(path string) *gitRepo {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
 	return g.pathToRepo[path]
 }
 
+// lookupRepo returns the repository registered for the path by [GitHandler.CanHandle].
+// Paths which were never registered, e.g. major version paths (/vN) probed for migrations,
+// are reported as not found.
+func (g *GitHandler) lookupRepo(path string) (*gitRepo, error) {
+	repo := g.getRepoForPath(path)
+	if repo == nil {
+		return nil, &NotFoundError{Query: path, Err: errors.Errorf("no git repository registered for %s", path)}
+	}
+	return repo, nil
+}
+
 func (g *GitHandler) initializeRepo(path string, repo *gitRepo) error {
 	if _, statErr := os.Stat(repo.DirPath); os.IsNotExist(statErr) {
 		return g.git.Clone(repo.URL, repo.DirPath)
@@ -190,10 +306,47 @@ func (g *GitHandler) initializeRepo(path string, repo *gitRepo) error {
 	return g.git.Pull(repo.DirPath)
 }
 
+// syncBareRepo creates or updates the bare clone used in lightweight mode.
+// It is only done once per repository.
+func (g *GitHandler) syncBareRepo(repo *gitRepo) error {
+	if repo.synced {
+		return nil
+	}
+	var err error
+	if _, statErr := os.Stat(repo.DirPath); os.IsNotExist(statErr) {
+		err = g.git.CloneBare(repo.URL, repo.DirPath)
+	} else {
+		err = g.git.FetchTags(repo.DirPath)
+	}
+	if err != nil {
+		return err
+	}
+	repo.synced = true
+	return nil
+}
+
+func (g *GitHandler) findTag(repo *gitRepo, version *semver.Version) (*gitTag, error) {
+	tags, err := g.listAllTags(repo)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range tags {
+		if tag.Version.String() == version.String() {
+			return &tag, nil
+		}
+	}
+	return nil, errors.Errorf("%s version not found for %s repository", version, repo.URL)
+}
+
 func (g *GitHandler) listAllTags(repo *gitRepo) ([]gitTag, error) {
 	if len(repo.tags) > 0 {
 		return repo.tags, nil
 	}
+	if g.lightweight {
+		if err := g.syncBareRepo(repo); err != nil {
+			return nil, err
+		}
+	}
 	tagsReader, err := g.git.ListTags(repo.DirPath)
 	if err != nil {
 		return nil, err
@@ -210,13 +363,14 @@ func (g *GitHandler) listAllTags(repo *gitRepo) ([]gitTag, error) {
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to parse date for line: %s", line)
 		}
-		version, err := semver.NewVersion(split[1])
-		if err != nil {
+		version, ok := parseTagName(split[1], repo.Subdir, repo.PathMajor)
+		if !ok {
 			continue
 		}
 		tags = append(tags, gitTag{
 			Version: version,
 			Date:    date,
+			Ref:     split[1],
 		})
 	}
 	if err := scanner.Err(); err != nil {
@@ -226,3 +380,72 @@ func (g *GitHandler) listAllTags(repo *gitRepo) ([]gitTag, error) {
 	repo.tags = tags
 	return tags, nil
 }
+
+// listRemoteTags lists the tags with 'git ls-remote', which does not provide tags' dates.
+func (g *GitHandler) listRemoteTags(repo *gitRepo) ([]gitTag, error) {
+	tagsReader, err := g.git.ListRemoteTags(repo.URL)
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(tagsReader)
+	tags := make([]gitTag, 0)
+	for scanner.Scan() {
+		line := scanner.Text()
+		_, ref, found := strings.Cut(line, "\t")
+		if !found {
+			return nil, errors.Errorf("unexpected 'git ls-remote' output line: %s, expected: '<hash>\t<ref>'", line)
+		}
+		ref = strings.TrimPrefix(ref, "refs/tags/")
+		version, ok := parseTagName(ref, repo.Subdir, repo.PathMajor)
+		if !ok {
+			continue
+		}
+		tags = append(tags, gitTag{
+			Version: version,
+			Ref:     ref,
+		})
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Version.LessThan(tags[j].Version) })
+	return tags, nil
+}
+
+// parseTagName extracts the version from the tag name.
+// Modules living in a repository subdirectory are tagged with the subdirectory prefix,
+// for instance 'sub/module/v1.2.3' for a module in 'sub/module' directory.
+// Tags which do not belong to the module living in subdir are rejected.
+// If pathMajor is set, versions with a different major version are rejected as well.
+// Module paths without major version suffix accept any major version,
+// since pre-module projects were not required to have one.
+func parseTagName(name, subdir, pathMajor string) (*semver.Version, bool) {
+	if subdir != "" {
+		var found bool
+		name, found = strings.CutPrefix(name, subdir+"/")
+		if !found {
+			return nil, false
+		}
+	}
+	if strings.Contains(name, "/") {
+		return nil, false
+	}
+	version, err := semver.NewVersion(name)
+	if err != nil {
+		return nil, false
+	}
+	if pathMajor != "" && pathMajor[1:] != "v"+strconv.FormatInt(version.Major(), 10) {
+		return nil, false
+	}
+	return version, true
+}
+
+// goModCandidates lists go.mod file paths, relative to the repository root,
+// which may define the module.
+func (r *gitRepo) goModCandidates() []string {
+	candidates := make([]string, 0, 2)
+	if r.PathMajor != "" {
+		candidates = append(candidates, pathlib.Join(r.Subdir, r.PathMajor[1:], "go.mod"))
+	}
+	return append(candidates, pathlib.Join(r.Subdir, "go.mod"))
+}
@@ -1,10 +1,13 @@
 package internal_test
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/Masterminds/semver"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
@@ -66,3 +69,155 @@ func TestGitHandler_CanHandle_PullIfCloned(t *testing.T) {
 	require.NoError(t, err)
 	assert.True(t, canHandle)
 }
+
+func TestGitHandler_Lightweight(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	tmpDir, err := os.MkdirTemp(os.TempDir(), "go-libyear-test")
+	require.NoError(t, err)
+	path := "github.com/nieomylnieja/go-libyear/sub/module"
+	url := "https://github.com/nieomylnieja/go-libyear.git"
+	dir := filepath.Join(tmpDir, path)
+
+	gitCmd := mocks.NewMockGitCmdI(ctrl)
+	gitCmd.EXPECT().
+		Clone(gomock.Any(), gomock.Any()).
+		Times(0)
+	gitCmd.EXPECT().
+		ListRemoteTags(url).
+		Times(1).
+		Return(bytes.NewBufferString(
+			"a1b2c3\trefs/tags/v1.0.0\n"+
+				"d4e5f6\trefs/tags/sub/module/v0.1.0\n"+
+				"a7b8c9\trefs/tags/sub/module/v0.2.0\n"+
+				"d1e2f3\trefs/tags/sub/other/v0.3.0\n"), nil)
+	gitCmd.EXPECT().
+		CloneBare(url, dir).
+		Times(1).
+		Return(nil)
+	gitCmd.EXPECT().
+		ListTags(dir).
+		Times(1).
+		Return(bytes.NewBufferString(
+			"2023-01-01 v1.0.0\n"+
+				"2023-01-02 sub/module/v0.1.0\n"+
+				"2023-01-03 sub/module/v0.2.0\n"), nil)
+	gitCmd.EXPECT().
+		ShowFile(dir, "sub/module/v0.2.0", "sub/module/go.mod").
+		Times(1).
+		Return([]byte("module "+path), nil)
+	git := internal.NewLightweightGitVCS(tmpDir, gitCmd)
+
+	canHandle, err := git.CanHandle(path)
+	require.NoError(t, err)
+	require.True(t, canHandle)
+
+	versions, err := git.GetVersions(path)
+	require.NoError(t, err)
+	assert.Equal(t, []*semver.Version{semver.MustParse("v0.1.0"), semver.MustParse("v0.2.0")}, versions)
+
+	latest, err := git.GetLatestInfo(path)
+	require.NoError(t, err)
+	assert.Equal(t, "v0.2.0", latest.Version.Original())
+	assert.Equal(t, "2023-01-03", latest.Time.Format(time.DateOnly))
+
+	goMod, err := git.GetModFile(path, latest.Version)
+	require.NoError(t, err)
+	assert.Equal(t, "module "+path, string(goMod))
+}
+
+func TestGitHandler_SubdirectoryModule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	tmpDir, err := os.MkdirTemp(os.TempDir(), "go-libyear-test")
+	require.NoError(t, err)
+	path := "github.com/nieomylnieja/go-libyear/tools/v2"
+	dir := filepath.Join(tmpDir, path)
+
+	gitCmd := mocks.NewMockGitCmdI(ctrl)
+	gitCmd.EXPECT().
+		Clone("https://github.com/nieomylnieja/go-libyear.git", dir).
+		Times(1).
+		DoAndReturn(func(_, dir string) error {
+			// Major branch layout go.mod declares different major version.
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, "tools", "v2"), 0o700))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "tools", "go.mod"),
+				[]byte("module github.com/nieomylnieja/go-libyear/tools\n"), 0o600))
+			return os.WriteFile(filepath.Join(dir, "tools", "v2", "go.mod"), []byte("module "+path+"\n"), 0o600)
+		})
+	gitCmd.EXPECT().
+		ListTags(dir).
+		Times(1).
+		Return(bytes.NewBufferString(
+			"2023-01-01 v2.5.0\n"+
+				"2023-01-02 tools/v1.4.0\n"+
+				"2023-01-03 tools/v2.0.0\n"+
+				"2023-01-04 tools/v2.1.0\n"+
+				"2023-01-05 other/v2.2.0\n"), nil)
+	gitCmd.EXPECT().
+		Checkout(dir, "tools/v2.1.0").
+		Times(1).
+		Return(nil)
+	git := internal.NewGitVCS(tmpDir, gitCmd)
+
+	canHandle, err := git.CanHandle(path)
+	require.NoError(t, err)
+	require.True(t, canHandle)
+
+	versions, err := git.GetVersions(path)
+	require.NoError(t, err)
+	assert.Equal(t, []*semver.Version{semver.MustParse("v2.0.0"), semver.MustParse("v2.1.0")}, versions)
+
+	goMod, err := git.GetModFile(path, semver.MustParse("v2.1.0"))
+	require.NoError(t, err)
+	assert.Equal(t, "module "+path+"\n", string(goMod))
+}
+
+func TestGitHandler_GetLatestInfo_Untagged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	tmpDir, err := os.MkdirTemp(os.TempDir(), "go-libyear-test")
+	require.NoError(t, err)
+	path := "github.com/nieomylnieja/go-libyear/v2"
+	dir := filepath.Join(tmpDir, path)
+
+	gitCmd := mocks.NewMockGitCmdI(ctrl)
+	gitCmd.EXPECT().
+		Clone(gomock.Any(), dir).
+		Times(1).
+		Return(nil)
+	gitCmd.EXPECT().
+		ListTags(dir).
+		Times(1).
+		Return(bytes.NewBufferString("2023-01-01 v1.0.0\n"), nil)
+	gitCmd.EXPECT().
+		GetLatestCommit(dir, "origin/HEAD").
+		Times(1).
+		Return(bytes.NewBufferString("abcdef1234567890abcdef1234567890abcdef12 2023-01-02T10:00:00+02:00\n"), nil)
+	git := internal.NewGitVCS(tmpDir, gitCmd)
+
+	_, err = git.CanHandle(path)
+	require.NoError(t, err)
+
+	latest, err := git.GetLatestInfo(path)
+	require.NoError(t, err)
+	assert.Equal(t, "v2.0.0-20230102080000-abcdef123456", latest.Version.Original())
+	assert.True(t, latest.IsPseudoVersion())
+}
+
+func TestGitHandler_UnregisteredPath(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	path := "github.com/nieomylnieja/go-libyear/v2"
+	gitCmd := mocks.NewMockGitCmdI(ctrl)
+	git := internal.NewLightweightGitVCS(t.TempDir(), gitCmd)
+
+	_, err := git.GetLatestInfo(path)
+	assert.True(t, internal.IsNotFound(err))
+	_, err = git.GetVersions(path)
+	assert.True(t, internal.IsNotFound(err))
+	_, err = git.GetInfo(path, semver.MustParse("v2.0.0"))
+	assert.True(t, internal.IsNotFound(err))
+	_, err = git.GetModFile(path, semver.MustParse("v2.0.0"))
+	assert.True(t, internal.IsNotFound(err))
+}
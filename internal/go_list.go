@@ -3,17 +3,18 @@ package internal
 import (
 	"bytes"
 	"encoding/json"
+	"strings"
 
 	"github.com/Masterminds/semver"
 
 	"github.com/pkg/errors"
 )
 
-func NewGoListExecutor(useCache bool, cacheFilePath string) (*GoListExecutor, error) {
+func NewGoListExecutor(useCache bool, cacheConfig CacheConfig) (*GoListExecutor, error) {
 	var cache modulesCache
 	if useCache {
 		var err error
-		cache, err = NewCache(cacheFilePath)
+		cache, err = NewCache(cacheConfig)
 		if err != nil {
 			return nil, err
 		}
@@ -25,7 +26,24 @@ type GoListExecutor struct {
 	cache modulesCache
 }
 
+func (e *GoListExecutor) String() string {
+	return "go list"
+}
+
+// SaveCacheStats persists the cache usage statistics of the current run, if the cache is enabled.
+func (e *GoListExecutor) SaveCacheStats() error {
+	if e.cache == nil {
+		return nil
+	}
+	return e.cache.SaveRunStats()
+}
+
 func (e *GoListExecutor) GetVersions(path string) ([]*semver.Version, error) {
+	if e.cache != nil {
+		if versions, loaded := e.cache.LoadVersions(path); loaded {
+			return versions, nil
+		}
+	}
 	out, err := e.exec("-versions", path)
 	if err != nil {
 		return nil, err
@@ -37,6 +55,11 @@ func (e *GoListExecutor) GetVersions(path string) ([]*semver.Version, error) {
 	if err = json.NewDecoder(out).Decode(&versions); err != nil {
 		return nil, err
 	}
+	if e.cache != nil {
+		if err = e.cache.SaveVersions(path, versions.Versions); err != nil {
+			return nil, err
+		}
+	}
 	return versions.Versions, nil
 }
 
@@ -45,7 +68,21 @@ func (e *GoListExecutor) GetInfo(path string, version *semver.Version) (*Module,
 }
 
 func (e *GoListExecutor) GetLatestInfo(path string) (*Module, error) {
-	return e.getInfo(path, nil, true)
+	if e.cache != nil {
+		if m, loaded := e.cache.LoadLatest(path); loaded {
+			return m, nil
+		}
+	}
+	m, err := e.getInfo(path, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	if e.cache != nil {
+		if err = e.cache.SaveLatest(m); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
 }
 
 // Fetch module details.
@@ -81,10 +118,53 @@ func (e *GoListExecutor) getInfo(path string, version *semver.Version, latest bo
 	return &m, nil
 }
 
+// ExcludesRetracted reports that the go command never lists retracted versions, nor selects them as latest.
+func (e *GoListExecutor) ExcludesRetracted() bool {
+	return true
+}
+
 func (e *GoListExecutor) GetModFile(_ string, _ *semver.Version) ([]byte, error) {
 	return nil, errors.New("retrieving go.mod file using GoListExecutor is not supported")
 }
 
 func (e *GoListExecutor) exec(args ...string) (*bytes.Buffer, error) {
-	return execCmd("go", append([]string{"list", "-json", "-m", "-mod=readonly"}, args...)...)
+	out, err := execCmd("go", append([]string{"list", "-json", "-m", "-mod=readonly"}, args...)...)
+	if err != nil && isGoListNotFound(err.Error()) {
+		return nil, &NotFoundError{Query: args[len(args)-1], Err: err}
+	}
+	return out, err
+}
+
+// NotFoundError is returned by GoListExecutor when the go command reports
+// that the requested module or version does not exist.
+// GitHandler returns it for module paths it has no repository for.
+type NotFoundError struct {
+	// Query is the module path, optionally followed by '@' and version query.
+	Query string
+	Err   error
+}
+
+func (e *NotFoundError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *NotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// goListNotFoundMessages are the fragments of the go command errors reported for missing modules or versions,
+// either resolved by the go command itself or returned by GOPROXY.
+var goListNotFoundMessages = []string{
+	"no matching versions",
+	"404 Not Found",
+	"410 Gone",
+}
+
+func isGoListNotFound(stderr string) bool {
+	for _, msg := range goListNotFoundMessages {
+		if strings.Contains(stderr, msg) {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,48 @@
+package internal
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsNotFound(t *testing.T) {
+	tests := map[string]struct {
+		Err      error
+		NotFound bool
+	}{
+		"nil": {},
+		"go list": {
+			Err:      errors.Wrap(&NotFoundError{Query: "github.com/a/b/v2@latest", Err: errors.New("failed")}, "wrapped"),
+			NotFound: true,
+		},
+		"proxy not found": {
+			Err:      errors.WithStack(&ResponseError{StatusCode: http.StatusNotFound}),
+			NotFound: true,
+		},
+		"proxy gone": {
+			Err:      &ResponseError{StatusCode: http.StatusGone},
+			NotFound: true,
+		},
+		"proxy error": {
+			Err: &ResponseError{StatusCode: http.StatusInternalServerError},
+		},
+		"untyped error": {
+			Err: errors.New("no matching versions for query \"latest\""),
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.NotFound, IsNotFound(test.Err))
+		})
+	}
+}
+
+func TestIsGoListNotFound(t *testing.T) {
+	assert.True(t, isGoListNotFound(`go: module github.com/a/b/v2: no matching versions for query "latest"`))
+	assert.True(t, isGoListNotFound("go: github.com/a/b/v2@latest: reading https://proxy.golang.org/"+
+		"github.com/a/b/v2/@v/list: 404 Not Found"))
+	assert.False(t, isGoListNotFound("go: github.com/a/b@latest: dial tcp: lookup proxy.golang.org: no such host"))
+}
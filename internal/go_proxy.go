@@ -9,17 +9,18 @@ import (
 	"os"
 	"regexp"
 	"strings"
-	"time"
 
 	"github.com/Masterminds/semver"
 	"github.com/pkg/errors"
+	"golang.org/x/sync/singleflight"
 )
 
-func NewGoProxyClient(useCache bool, cacheFilePath string) (*GoProxyClient, error) {
+// NewGoProxyClient creates a client for the GOPROXY server, if client is nil, [DefaultHTTPConfig] is used.
+func NewGoProxyClient(client *http.Client, useCache bool, cacheConfig CacheConfig) (*GoProxyClient, error) {
 	var cache modulesCache
 	if useCache {
 		var err error
-		cache, err = NewCache(cacheFilePath)
+		cache, err = NewCache(cacheConfig)
 		if err != nil {
 			return nil, err
 		}
@@ -33,7 +34,7 @@ func NewGoProxyClient(useCache bool, cacheFilePath string) (*GoProxyClient, erro
 		apiURL = *u
 	}
 	return &GoProxyClient{
-		http:   &http.Client{Timeout: 10 * time.Second},
+		http:   httpClientOrDefault(client),
 		apiURL: apiURL,
 		cache:  cache,
 	}, nil
@@ -42,9 +43,29 @@ func NewGoProxyClient(useCache bool, cacheFilePath string) (*GoProxyClient, erro
 // GoProxyClient is used to interact with Golang proxy server.
 // Details on GOPROXY protocol can be found here: https://go.dev/ref/mod#goproxy-protocol.
 type GoProxyClient struct {
-	http   *http.Client
-	apiURL url.URL
-	cache  modulesCache
+	http     *http.Client
+	apiURL   url.URL
+	cache    modulesCache
+	limiter  concurrencyLimiter
+	inflight singleflight.Group
+}
+
+// SetConcurrencyLimit limits the number of concurrent requests sent to GOPROXY, zero means no limit.
+// Concurrent requests for the same resource are always sent only once and share the response.
+func (c *GoProxyClient) SetConcurrencyLimit(limit int) {
+	c.limiter = newConcurrencyLimiter(limit)
+}
+
+func (c *GoProxyClient) String() string {
+	return "GOPROXY " + c.apiURL.Redacted()
+}
+
+// SaveCacheStats persists the cache usage statistics of the current run, if the cache is enabled.
+func (c *GoProxyClient) SaveCacheStats() error {
+	if c.cache == nil {
+		return nil
+	}
+	return c.cache.SaveRunStats()
 }
 
 const (
@@ -59,7 +80,21 @@ func (c *GoProxyClient) GetInfo(path string, version *semver.Version) (*Module,
 }
 
 func (c *GoProxyClient) GetLatestInfo(path string) (*Module, error) {
-	return c.getInfo(path, nil, true)
+	if c.cache != nil {
+		if m, loaded := c.cache.LoadLatest(path); loaded {
+			return m, nil
+		}
+	}
+	m, err := c.getInfo(path, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	if c.cache != nil {
+		if err = c.cache.SaveLatest(m); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
 }
 
 func (c *GoProxyClient) getInfo(path string, version *semver.Version, latest bool) (*Module, error) {
@@ -96,8 +131,12 @@ func (c *GoProxyClient) getInfo(path string, version *semver.Version, latest boo
 }
 
 func (c *GoProxyClient) GetVersions(path string) ([]*semver.Version, error) {
-	path = escapePath(path)
-	data, err := c.query(fmt.Sprintf(getVersionsFmt, path))
+	if c.cache != nil {
+		if versions, loaded := c.cache.LoadVersions(path); loaded {
+			return versions, nil
+		}
+	}
+	data, err := c.query(fmt.Sprintf(getVersionsFmt, escapePath(path)))
 	if err != nil {
 		return nil, err
 	}
@@ -113,29 +152,85 @@ func (c *GoProxyClient) GetVersions(path string) ([]*semver.Version, error) {
 		}
 		versions = append(versions, v)
 	}
+	if c.cache != nil {
+		if err = c.cache.SaveVersions(path, versions); err != nil {
+			return nil, err
+		}
+	}
 	return versions, nil
 }
 
 func (c *GoProxyClient) GetModFile(path string, version *semver.Version) ([]byte, error) {
+	if c.cache != nil {
+		if data, loaded := c.cache.LoadModFile(path, version); loaded {
+			return data, nil
+		}
+	}
 	urlPath := fmt.Sprintf(getModFileFmt, escapePath(path), version)
-	return c.query(urlPath)
+	data, err := c.query(urlPath)
+	if err != nil {
+		return nil, err
+	}
+	if c.cache != nil {
+		if err = c.cache.SaveModFile(path, version, data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
 }
 
 func (c *GoProxyClient) query(urlPath string) ([]byte, error) {
+	return doShared(&c.inflight, urlPath, func() ([]byte, error) {
+		c.limiter.acquire()
+		defer c.limiter.release()
+		return c.get(urlPath)
+	})
+}
+
+func (c *GoProxyClient) get(urlPath string) ([]byte, error) {
 	resp, err := c.http.Get(c.apiURL.JoinPath(urlPath).String())
 	if err != nil {
 		return nil, err
 	}
+	defer func() { _ = resp.Body.Close() }()
 	if resp.StatusCode != http.StatusOK {
 		data, _ := io.ReadAll(resp.Body)
-		return nil, errors.Errorf(
-			"unexpected response status code from %s %s: %d, body: %s",
-			http.MethodGet, resp.Request.URL.String(), resp.StatusCode, string(data))
+		return nil, errors.WithStack(&ResponseError{
+			Method:     http.MethodGet,
+			URL:        resp.Request.URL.Redacted(),
+			StatusCode: resp.StatusCode,
+			Body:       string(data),
+		})
 	}
-	defer func() { _ = resp.Body.Close() }()
 	return io.ReadAll(resp.Body)
 }
 
+// ResponseError is returned when the server responds with an unexpected status code.
+type ResponseError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Body       string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("unexpected response status code from %s %s: %d, body: %s",
+		e.Method, e.URL, e.StatusCode, e.Body)
+}
+
+// IsNotFound reports whether the error means that the requested module or version does not exist.
+func IsNotFound(err error) bool {
+	if err == nil {
+		return false
+	}
+	var respErr *ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.StatusCode == http.StatusNotFound || respErr.StatusCode == http.StatusGone
+	}
+	var notFoundErr *NotFoundError
+	return errors.As(err, &notFoundErr)
+}
+
 var uppercaseRegex = regexp.MustCompile(`[A-Z]`)
 
 func escapePath(path string) string {
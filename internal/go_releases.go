@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const defaultGoReleasesURL = "https://go.dev/dl/?mode=json&include=all"
+
+// NewGoReleasesClient creates a client which lists Go toolchain releases.
+// The source can be either a URL serving the releases in go.dev/dl JSON format,
+// or a path to a local file with the same contents.
+// If the source is empty, go.dev/dl is queried.
+// If client is nil, [DefaultHTTPConfig] is used.
+func NewGoReleasesClient(client *http.Client, source string) *GoReleasesClient {
+	if source == "" {
+		source = defaultGoReleasesURL
+	}
+	return &GoReleasesClient{
+		http:   httpClientOrDefault(client),
+		source: source,
+	}
+}
+
+type GoReleasesClient struct {
+	http   *http.Client
+	source string
+}
+
+// GoRelease is a single Go toolchain release.
+type GoRelease struct {
+	// Version of the release, e.g. 'go1.21.3'.
+	Version string `json:"version"`
+	Stable  bool   `json:"stable"`
+	// Time is the release date.
+	// It is not part of go.dev/dl response, but custom sources may provide it.
+	Time time.Time `json:"time"`
+}
+
+func (c *GoReleasesClient) GetGoReleases() ([]GoRelease, error) {
+	data, err := c.read()
+	if err != nil {
+		return nil, err
+	}
+	var releases []GoRelease
+	if err = json.Unmarshal(data, &releases); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode Go releases from %s", c.source)
+	}
+	return releases, nil
+}
+
+func (c *GoReleasesClient) read() ([]byte, error) {
+	if !strings.HasPrefix(c.source, "http://") && !strings.HasPrefix(c.source, "https://") {
+		return os.ReadFile(c.source)
+	}
+	u, err := url.Parse(c.source)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return nil, errors.Errorf(
+			"unexpected response status code from %s %s: %d, body: %s",
+			http.MethodGet, u.String(), resp.StatusCode, string(data))
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// legacyGoReleaseDates lists the release dates of Go versions which predate
+// golang.org/toolchain module, which was introduced in Go 1.21.
+// Before Go 1.21 'go' directive could only declare the language version.
+var legacyGoReleaseDates = map[string]string{
+	"go1":    "2012-03-28",
+	"go1.1":  "2013-05-13",
+	"go1.2":  "2013-12-01",
+	"go1.3":  "2014-06-18",
+	"go1.4":  "2014-12-10",
+	"go1.5":  "2015-08-19",
+	"go1.6":  "2016-02-17",
+	"go1.7":  "2016-08-15",
+	"go1.8":  "2017-02-16",
+	"go1.9":  "2017-08-24",
+	"go1.10": "2018-02-16",
+	"go1.11": "2018-08-24",
+	"go1.12": "2019-02-25",
+	"go1.13": "2019-09-03",
+	"go1.14": "2020-02-25",
+	"go1.15": "2020-08-11",
+	"go1.16": "2021-02-16",
+	"go1.17": "2021-08-16",
+	"go1.18": "2022-03-15",
+	"go1.19": "2022-08-02",
+	"go1.20": "2023-02-01",
+}
+
+// LegacyGoReleaseTime returns the release date of a Go language version older than Go 1.21.
+func LegacyGoReleaseTime(lang string) (time.Time, bool) {
+	date, ok := legacyGoReleaseDates[lang]
+	if !ok {
+		return time.Time{}, false
+	}
+	t, _ := time.Parse(time.DateOnly, date)
+	return t, true
+}
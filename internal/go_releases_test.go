@@ -0,0 +1,42 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoReleasesClient_GetGoReleases(t *testing.T) {
+	const releases = `[
+  {"version": "go1.22.1", "stable": true, "files": []},
+  {"version": "go1.23rc1", "stable": false, "files": []}
+]`
+	expected := []GoRelease{
+		{Version: "go1.22.1", Stable: true},
+		{Version: "go1.23rc1", Stable: false},
+	}
+
+	t.Run("url", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(releases))
+		}))
+		defer srv.Close()
+
+		actual, err := NewGoReleasesClient(nil, srv.URL).GetGoReleases()
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "releases.json")
+		require.NoError(t, os.WriteFile(path, []byte(releases), 0o600))
+
+		actual, err := NewGoReleasesClient(nil, path).GetGoReleases()
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+}
@@ -0,0 +1,64 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+// GoSum holds go.mod files' hashes listed in go.sum file.
+// It is keyed by module path and version, e.g. github.com/pkg/errors@v0.9.1.
+type GoSum map[string]string
+
+// ReadGoSumFile reads go.sum file at the given path.
+func ReadGoSumFile(path string) (GoSum, error) {
+	// #nosec G304
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ReadGoSum(data)
+}
+
+// ReadGoSum parses go.sum file contents, only go.mod files' hashes are retained.
+func ReadGoSum(data []byte) (GoSum, error) {
+	goSum := make(GoSum)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			return nil, errors.Errorf("malformed go.sum line %d: expected 3 fields, got %d", lineNum, len(fields))
+		}
+		version, isModFile := strings.CutSuffix(fields[1], "/go.mod")
+		if !isModFile {
+			continue
+		}
+		goSum[fields[0]+"@"+version] = fields[2]
+	}
+	return goSum, scanner.Err()
+}
+
+// ModFileHash returns the hash of the module's go.mod file, if it's listed.
+func (g GoSum) ModFileHash(path string, version *semver.Version) (string, bool) {
+	if g == nil || version == nil {
+		return "", false
+	}
+	hash, ok := g[path+"@v"+version.String()]
+	return hash, ok
+}
+
+// HashModFile computes the hash of the go.mod file contents, as listed in go.sum file.
+func HashModFile(data []byte) (string, error) {
+	return dirhash.Hash1([]string{"go.mod"}, func(string) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	})
+}
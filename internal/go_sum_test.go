@@ -0,0 +1,37 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testGoSum = `github.com/pkg/errors v0.9.1 h1:FEBLx1zS214owpjy7qsBeixbURkuhQAwrK5UwLGTwt4=
+github.com/pkg/errors v0.9.1/go.mod h1:bwawxfHBFNV+L2hUp1rHADufV3IMtnDRdf1r5NINEl0=
+
+github.com/a/b v2.0.0+incompatible/go.mod h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=
+`
+
+func TestReadGoSum(t *testing.T) {
+	goSum, err := ReadGoSum([]byte(testGoSum))
+	require.NoError(t, err)
+
+	hash, listed := goSum.ModFileHash("github.com/pkg/errors", semver.MustParse("v0.9.1"))
+	assert.True(t, listed)
+	assert.Equal(t, "h1:bwawxfHBFNV+L2hUp1rHADufV3IMtnDRdf1r5NINEl0=", hash)
+	_, listed = goSum.ModFileHash("github.com/a/b", semver.MustParse("v2.0.0+incompatible"))
+	assert.True(t, listed)
+	_, listed = goSum.ModFileHash("github.com/pkg/errors", semver.MustParse("v0.9.0"))
+	assert.False(t, listed)
+
+	_, err = ReadGoSum([]byte("github.com/pkg/errors v0.9.1\n"))
+	assert.EqualError(t, err, "malformed go.sum line 1: expected 3 fields, got 2")
+}
+
+func TestHashModFile(t *testing.T) {
+	hash, err := HashModFile([]byte("module github.com/pkg/errors\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "h1:bwawxfHBFNV+L2hUp1rHADufV3IMtnDRdf1r5NINEl0=", hash)
+}
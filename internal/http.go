@@ -0,0 +1,302 @@
+package internal
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultHTTPTimeout is the default timeout of a single request attempt.
+	DefaultHTTPTimeout = 10 * time.Second
+	// DefaultHTTPMaxRetries is the default number of retries of a failed request.
+	DefaultHTTPMaxRetries = 3
+	// DefaultHTTPMinBackoff is the default delay before the first retry.
+	DefaultHTTPMinBackoff = 500 * time.Millisecond
+	// DefaultHTTPMaxBackoff is the default maximum delay between retries.
+	DefaultHTTPMaxBackoff = 30 * time.Second
+)
+
+// HTTPConfig configures the retries and rate limiting of the clients created with [NewHTTPClient].
+// Zero timeout and backoffs fall back to the defaults.
+type HTTPConfig struct {
+	// Timeout of a single request attempt, including reading the response body.
+	Timeout time.Duration
+	// MaxRetries is the maximum number of retries of a failed request, zero disables retries.
+	MaxRetries int
+	// MinBackoff is the delay before the first retry, it is doubled with each retry up to MaxBackoff.
+	MinBackoff time.Duration
+	// MaxBackoff is the maximum delay between retries.
+	// Responses asking to retry after a longer period with Retry-After header are not retried.
+	MaxBackoff time.Duration
+	// MaxConcurrentPerHost limits the number of in-flight requests to a single host, zero means no limit.
+	MaxConcurrentPerHost int
+	// RequestsPerSecondPerHost limits the rate of requests sent to a single host, zero means no limit.
+	RequestsPerSecondPerHost float64
+	// Auth provides the credentials of the requests, if nil, requests are not authenticated.
+	Auth *HTTPAuth
+}
+
+// DefaultHTTPConfig returns [HTTPConfig] with retries enabled and no rate limits.
+func DefaultHTTPConfig() HTTPConfig {
+	return HTTPConfig{
+		Timeout:    DefaultHTTPTimeout,
+		MaxRetries: DefaultHTTPMaxRetries,
+		MinBackoff: DefaultHTTPMinBackoff,
+		MaxBackoff: DefaultHTTPMaxBackoff,
+	}
+}
+
+// NewHTTPClient returns a client which retries failed idempotent requests with exponential backoff and jitter,
+// limits the requests sent to each host and authenticates them, if [HTTPConfig.Auth] is set.
+// Failed requests are the ones which returned a network error, 429 or 5xx status code.
+// The base client's transport is wrapped, the base client itself is not modified.
+// If base is nil, [http.DefaultTransport] is used.
+func NewHTTPClient(base *http.Client, config HTTPConfig) *http.Client {
+	var client http.Client
+	if base != nil {
+		client = *base
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultHTTPTimeout
+	}
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = DefaultHTTPMinBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = DefaultHTTPMaxBackoff
+	}
+	transport := client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if config.Auth != nil {
+		transport = &authTransport{base: transport, auth: config.Auth}
+	}
+	client.Transport = &retryTransport{
+		base:     transport,
+		config:   config,
+		limiters: make(map[string]*hostLimiter),
+	}
+	return &client
+}
+
+// httpClientOrDefault returns the client, or a new [NewHTTPClient] with [DefaultHTTPConfig] if it's nil.
+func httpClientOrDefault(client *http.Client) *http.Client {
+	if client != nil {
+		return client
+	}
+	return NewHTTPClient(nil, DefaultHTTPConfig())
+}
+
+// retryTransport implements [http.RoundTripper] with retries and per-host rate limiting.
+type retryTransport struct {
+	base     http.RoundTripper
+	config   HTTPConfig
+	mu       sync.Mutex
+	limiters map[string]*hostLimiter
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	limiter := t.hostLimiter(req.URL.Host)
+	for attempt := 0; ; attempt++ {
+		resp, err := t.roundTrip(req, limiter, attempt)
+		delay, retry := t.retryDelay(req, resp, err, attempt)
+		if !retry {
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+		if err = sleepContext(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// roundTrip sends a single attempt of the request.
+// The host's concurrency slot and the attempt's timeout are released once the response body is closed.
+func (t *retryTransport) roundTrip(req *http.Request, limiter *hostLimiter, attempt int) (*http.Response, error) {
+	if err := limiter.acquire(req.Context()); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.config.Timeout)
+	attemptReq := req.Clone(ctx)
+	if attempt > 0 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			limiter.release()
+			return nil, err
+		}
+		attemptReq.Body = body
+	}
+	resp, err := t.base.RoundTrip(attemptReq)
+	if err != nil {
+		cancel()
+		limiter.release()
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: func() {
+		cancel()
+		limiter.release()
+	}}
+	return resp, nil
+}
+
+// retryDelay returns the delay after which the request should be retried, if it should be retried at all.
+func (t *retryTransport) retryDelay(
+	req *http.Request,
+	resp *http.Response,
+	err error,
+	attempt int,
+) (time.Duration, bool) {
+	if attempt >= t.config.MaxRetries || req.Context().Err() != nil {
+		return 0, false
+	}
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return 0, false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return 0, false
+	}
+	delay := t.backoff(attempt)
+	if err != nil {
+		return delay, true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+	default:
+		return 0, false
+	}
+	if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+		if retryAfter > t.config.MaxBackoff {
+			return 0, false
+		}
+		delay = max(delay, retryAfter)
+	}
+	return delay, true
+}
+
+// backoff returns exponentially growing delay with jitter, it's between half and the full backoff.
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	backoff := t.config.MaxBackoff
+	if attempt < 32 {
+		backoff = min(t.config.MinBackoff<<attempt, t.config.MaxBackoff)
+	}
+	half := backoff / 2
+	return half + rand.N(backoff-half+1)
+}
+
+func (t *retryTransport) hostLimiter(host string) *hostLimiter {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	limiter, ok := t.limiters[host]
+	if !ok {
+		limiter = newHostLimiter(t.config.MaxConcurrentPerHost, t.config.RequestsPerSecondPerHost)
+		t.limiters[host] = limiter
+	}
+	return limiter
+}
+
+// parseRetryAfter parses Retry-After header value, which is either a number of seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// hostLimiter limits the number of in-flight requests and spaces out the requests evenly
+// to achieve the requested rate.
+type hostLimiter struct {
+	slots    chan struct{}
+	interval time.Duration
+	mu       sync.Mutex
+	next     time.Time
+}
+
+func newHostLimiter(maxConcurrent int, requestsPerSecond float64) *hostLimiter {
+	limiter := &hostLimiter{}
+	if maxConcurrent > 0 {
+		limiter.slots = make(chan struct{}, maxConcurrent)
+	}
+	if requestsPerSecond > 0 {
+		limiter.interval = time.Duration(float64(time.Second) / requestsPerSecond)
+	}
+	return limiter
+}
+
+func (l *hostLimiter) acquire(ctx context.Context) error {
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if l.interval <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	start := now
+	if l.next.After(now) {
+		start = l.next
+	}
+	l.next = start.Add(l.interval)
+	l.mu.Unlock()
+	if err := sleepContext(ctx, start.Sub(now)); err != nil {
+		l.release()
+		return err
+	}
+	return nil
+}
+
+func (l *hostLimiter) release() {
+	if l.slots != nil {
+		<-l.slots
+	}
+}
+
+// releasingBody calls release exactly once, when the body is closed.
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
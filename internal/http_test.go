@@ -0,0 +1,181 @@
+package internal
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHTTPConfig() HTTPConfig {
+	return HTTPConfig{MaxRetries: 3, MinBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+}
+
+func TestHTTPClient_Retries(t *testing.T) {
+	tests := map[string]struct {
+		Method    string
+		Responses []int
+		Header    http.Header
+		Status    int
+		Attempts  int32
+	}{
+		"success after transient errors": {
+			Responses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK},
+			Status:    http.StatusOK,
+			Attempts:  3,
+		},
+		"retries exhausted": {
+			Responses: []int{500, 502, 503, 504, 200},
+			Status:    http.StatusGatewayTimeout,
+			Attempts:  4,
+		},
+		"not found is not retried": {
+			Responses: []int{http.StatusNotFound, http.StatusOK},
+			Status:    http.StatusNotFound,
+			Attempts:  1,
+		},
+		"non-idempotent request is not retried": {
+			Method:    http.MethodPost,
+			Responses: []int{http.StatusServiceUnavailable, http.StatusOK},
+			Status:    http.StatusServiceUnavailable,
+			Attempts:  1,
+		},
+		"Retry-After exceeding max backoff is not retried": {
+			Responses: []int{http.StatusTooManyRequests, http.StatusOK},
+			Header:    http.Header{"Retry-After": []string{"120"}},
+			Status:    http.StatusTooManyRequests,
+			Attempts:  1,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				attempt := attempts.Add(1)
+				for k, v := range test.Header {
+					w.Header()[k] = v
+				}
+				w.WriteHeader(test.Responses[attempt-1])
+				_, _ = w.Write([]byte("body"))
+			}))
+			defer srv.Close()
+			client := NewHTTPClient(nil, newTestHTTPConfig())
+			method := test.Method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req, err := http.NewRequest(method, srv.URL, strings.NewReader(""))
+			require.NoError(t, err)
+			if method == http.MethodGet {
+				req.Body, req.GetBody = nil, nil
+			}
+
+			resp, err := client.Do(req)
+
+			require.NoError(t, err)
+			defer func() { _ = resp.Body.Close() }()
+			assert.Equal(t, test.Status, resp.StatusCode)
+			assert.Equal(t, test.Attempts, attempts.Load())
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, "body", string(body))
+		})
+	}
+}
+
+func TestHTTPClient_NetworkError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	srv.Close()
+	var attempts atomic.Int32
+	base := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		attempts.Add(1)
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+
+	_, err := NewHTTPClient(base, newTestHTTPConfig()).Get(srv.URL)
+
+	require.Error(t, err)
+	assert.Equal(t, int32(4), attempts.Load())
+}
+
+func TestHTTPClient_HostConcurrencyLimit(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			observed := maxInFlight.Load()
+			if current <= observed || maxInFlight.CompareAndSwap(observed, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}))
+	defer srv.Close()
+	config := newTestHTTPConfig()
+	config.MaxConcurrentPerHost = 2
+	client := NewHTTPClient(nil, config)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(srv.URL)
+			if assert.NoError(t, err) {
+				_ = resp.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(2), maxInFlight.Load())
+}
+
+func TestHTTPClient_HostRateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+	config := newTestHTTPConfig()
+	config.RequestsPerSecondPerHost = 100
+	client := NewHTTPClient(nil, config)
+
+	start := time.Now()
+	for range 5 {
+		resp, err := client.Get(srv.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		Delay time.Duration
+		OK    bool
+	}{
+		"":                              {},
+		"invalid":                       {},
+		"5":                             {Delay: 5 * time.Second, OK: true},
+		"-1":                            {Delay: 0, OK: true},
+		"Mon, 01 Jan 2024 00:00:30 GMT": {Delay: 30 * time.Second, OK: true},
+		"Sun, 31 Dec 2023 00:00:00 GMT": {Delay: 0, OK: true},
+	}
+	for value, test := range tests {
+		delay, ok := parseRetryAfter(value, now)
+		assert.Equal(t, test.OK, ok, value)
+		assert.Equal(t, test.Delay, delay, value)
+	}
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
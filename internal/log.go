@@ -0,0 +1,11 @@
+package internal
+
+import "log/slog"
+
+// loggerOrDefault returns the logger, or [slog.Default] if it's nil.
+func loggerOrDefault(logger *slog.Logger) *slog.Logger {
+	if logger != nil {
+		return logger
+	}
+	return slog.Default()
+}
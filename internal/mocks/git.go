@@ -115,6 +115,82 @@ func (c *MockGitCmdICloneCall) DoAndReturn(f func(string, string) error) *MockGi
 	return c
 }
 
+// CloneBare mocks base method.
+func (m *MockGitCmdI) CloneBare(arg0, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloneBare", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloneBare indicates an expected call of CloneBare.
+func (mr *MockGitCmdIMockRecorder) CloneBare(arg0, arg1 any) *MockGitCmdICloneBareCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloneBare", reflect.TypeOf((*MockGitCmdI)(nil).CloneBare), arg0, arg1)
+	return &MockGitCmdICloneBareCall{Call: call}
+}
+
+// MockGitCmdICloneBareCall wrap *gomock.Call
+type MockGitCmdICloneBareCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockGitCmdICloneBareCall) Return(arg0 error) *MockGitCmdICloneBareCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockGitCmdICloneBareCall) Do(f func(string, string) error) *MockGitCmdICloneBareCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockGitCmdICloneBareCall) DoAndReturn(f func(string, string) error) *MockGitCmdICloneBareCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// FetchTags mocks base method.
+func (m *MockGitCmdI) FetchTags(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchTags", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FetchTags indicates an expected call of FetchTags.
+func (mr *MockGitCmdIMockRecorder) FetchTags(arg0 any) *MockGitCmdIFetchTagsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchTags", reflect.TypeOf((*MockGitCmdI)(nil).FetchTags), arg0)
+	return &MockGitCmdIFetchTagsCall{Call: call}
+}
+
+// MockGitCmdIFetchTagsCall wrap *gomock.Call
+type MockGitCmdIFetchTagsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockGitCmdIFetchTagsCall) Return(arg0 error) *MockGitCmdIFetchTagsCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockGitCmdIFetchTagsCall) Do(f func(string) error) *MockGitCmdIFetchTagsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockGitCmdIFetchTagsCall) DoAndReturn(f func(string) error) *MockGitCmdIFetchTagsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // GetHeadBranchName mocks base method.
 func (m *MockGitCmdI) GetHeadBranchName(arg0 string) (string, error) {
 	m.ctrl.T.Helper()
@@ -154,6 +230,84 @@ func (c *MockGitCmdIGetHeadBranchNameCall) DoAndReturn(f func(string) (string, e
 	return c
 }
 
+// GetLatestCommit mocks base method.
+func (m *MockGitCmdI) GetLatestCommit(arg0, arg1 string) (io.Reader, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestCommit", arg0, arg1)
+	ret0, _ := ret[0].(io.Reader)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestCommit indicates an expected call of GetLatestCommit.
+func (mr *MockGitCmdIMockRecorder) GetLatestCommit(arg0, arg1 any) *MockGitCmdIGetLatestCommitCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestCommit", reflect.TypeOf((*MockGitCmdI)(nil).GetLatestCommit), arg0, arg1)
+	return &MockGitCmdIGetLatestCommitCall{Call: call}
+}
+
+// MockGitCmdIGetLatestCommitCall wrap *gomock.Call
+type MockGitCmdIGetLatestCommitCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockGitCmdIGetLatestCommitCall) Return(arg0 io.Reader, arg1 error) *MockGitCmdIGetLatestCommitCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockGitCmdIGetLatestCommitCall) Do(f func(string, string) (io.Reader, error)) *MockGitCmdIGetLatestCommitCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockGitCmdIGetLatestCommitCall) DoAndReturn(f func(string, string) (io.Reader, error)) *MockGitCmdIGetLatestCommitCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// ListRemoteTags mocks base method.
+func (m *MockGitCmdI) ListRemoteTags(arg0 string) (io.Reader, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRemoteTags", arg0)
+	ret0, _ := ret[0].(io.Reader)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRemoteTags indicates an expected call of ListRemoteTags.
+func (mr *MockGitCmdIMockRecorder) ListRemoteTags(arg0 any) *MockGitCmdIListRemoteTagsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRemoteTags", reflect.TypeOf((*MockGitCmdI)(nil).ListRemoteTags), arg0)
+	return &MockGitCmdIListRemoteTagsCall{Call: call}
+}
+
+// MockGitCmdIListRemoteTagsCall wrap *gomock.Call
+type MockGitCmdIListRemoteTagsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockGitCmdIListRemoteTagsCall) Return(arg0 io.Reader, arg1 error) *MockGitCmdIListRemoteTagsCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockGitCmdIListRemoteTagsCall) Do(f func(string) (io.Reader, error)) *MockGitCmdIListRemoteTagsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockGitCmdIListRemoteTagsCall) DoAndReturn(f func(string) (io.Reader, error)) *MockGitCmdIListRemoteTagsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // ListTags mocks base method.
 func (m *MockGitCmdI) ListTags(arg0 string) (io.Reader, error) {
 	m.ctrl.T.Helper()
@@ -230,3 +384,42 @@ func (c *MockGitCmdIPullCall) DoAndReturn(f func(string) error) *MockGitCmdIPull
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
+
+// ShowFile mocks base method.
+func (m *MockGitCmdI) ShowFile(arg0, arg1, arg2 string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShowFile", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ShowFile indicates an expected call of ShowFile.
+func (mr *MockGitCmdIMockRecorder) ShowFile(arg0, arg1, arg2 any) *MockGitCmdIShowFileCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShowFile", reflect.TypeOf((*MockGitCmdI)(nil).ShowFile), arg0, arg1, arg2)
+	return &MockGitCmdIShowFileCall{Call: call}
+}
+
+// MockGitCmdIShowFileCall wrap *gomock.Call
+type MockGitCmdIShowFileCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockGitCmdIShowFileCall) Return(arg0 []byte, arg1 error) *MockGitCmdIShowFileCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockGitCmdIShowFileCall) Do(f func(string, string, string) ([]byte, error)) *MockGitCmdIShowFileCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockGitCmdIShowFileCall) DoAndReturn(f func(string, string, string) ([]byte, error)) *MockGitCmdIShowFileCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
@@ -3,11 +3,14 @@ package internal
 import (
 	"fmt"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/Masterminds/semver"
 
 	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	gosemver "golang.org/x/mod/semver"
 )
 
 const ProgramName = "go-libyear"
@@ -30,6 +33,256 @@ type Module struct {
 	// AllPaths preceding this version, if any.
 	// This field is only set for latest version.
 	AllPaths []string `json:"-"`
+	// Retracted is set if the version was retracted by the module authors.
+	Retracted bool `json:"-"`
+	// Deprecated is the deprecation message of the module, if it was deprecated.
+	Deprecated string `json:"-"`
+	// Replace is set if the module was affected by a replace directive.
+	Replace *Replace `json:"-"`
+	// Vulnerabilities lists known vulnerabilities affecting the current version.
+	Vulnerabilities []Vulnerability `json:"-"`
+	// Fixed is the first version which fixes all known vulnerabilities.
+	// It is not set if any of the vulnerabilities was not fixed yet.
+	Fixed *Module `json:"-"`
+	// FixedLibyear is the libyear between the current and fixed versions.
+	FixedLibyear float64 `json:"-"`
+	// Activity describes the module's release cadence.
+	Activity *Activity `json:"-"`
+	// Usage describes how the module's packages are used by the main module.
+	Usage *PackageUsage `json:"-"`
+	// Tool is set for tool modules, which are required only in order to build the tools
+	// declared with 'tool' directives, see markToolModules.
+	Tool bool `json:"-"`
+	// Migration is the latest version of the module-aware major version path (/vN),
+	// which the +incompatible module migrated to.
+	Migration *Module `json:"-"`
+	// MigrationLibyear is the libyear between the current and migration versions.
+	MigrationLibyear float64 `json:"-"`
+	// ChecksumMismatch is set if the module's go.mod file did not match the go.sum or checksum database hash.
+	ChecksumMismatch *ChecksumMismatchError `json:"-"`
+}
+
+// Activity describes how actively the module is maintained, based on its releases.
+type Activity struct {
+	// LastRelease is the release date of the most recent version.
+	LastRelease time.Time
+	// SinceLastRelease is the time passed since the last release.
+	SinceLastRelease time.Duration
+	// MedianReleaseInterval is the median time between the most recent releases.
+	MedianReleaseInterval time.Duration
+	// Inactive is set if there was no release for longer than the configured period.
+	Inactive bool
+}
+
+// ReplaceKind describes how the module was affected by a replace directive.
+type ReplaceKind int
+
+const (
+	// ReplaceVersion means the module was replaced with a different version of itself.
+	ReplaceVersion ReplaceKind = iota + 1
+	// ReplaceFork means the module is a replacement (usually a fork) of a different module.
+	ReplaceFork
+	// ReplaceUpstream means the module was replaced with a different module (usually a fork).
+	// It is not used by the main module, but it's analyzed to show how far the fork drifted.
+	ReplaceUpstream
+	// ReplaceLocal means the module was replaced with a local filesystem path.
+	ReplaceLocal
+)
+
+func (k ReplaceKind) String() string {
+	switch k {
+	case ReplaceVersion:
+		return "version"
+	case ReplaceFork:
+		return "fork"
+	case ReplaceUpstream:
+		return "upstream"
+	case ReplaceLocal:
+		return "local"
+	default:
+		return "unknown"
+	}
+}
+
+// Replace contains the details of a replace directive affecting the module.
+type Replace struct {
+	Kind ReplaceKind
+	// Path depends on the Kind:
+	//   - ReplaceVersion: the module path
+	//   - ReplaceFork: the replaced (upstream) module path
+	//   - ReplaceUpstream: the replacement (fork) module path
+	//   - ReplaceLocal: the local filesystem path
+	Path string
+	// Version is the originally required version, it is not set for ReplaceLocal.
+	Version *semver.Version
+}
+
+// IsUpstream reports whether the module was replaced by a different module
+// and only serves as a reference point for its replacement.
+func (m *Module) IsUpstream() bool {
+	return m.Replace != nil && m.Replace.Kind == ReplaceUpstream
+}
+
+// IsLocal reports whether the module was replaced by a local filesystem path.
+func (m *Module) IsLocal() bool {
+	return m.Replace != nil && m.Replace.Kind == ReplaceLocal
+}
+
+// IsPseudoVersion reports whether the module's version is a pseudo-version,
+// which references an untagged revision, e.g. 'v0.0.0-20230101120000-abcdef123456'.
+func (m *Module) IsPseudoVersion() bool {
+	return m.Version != nil && module.IsPseudoVersion(goVersion(m.Version))
+}
+
+// IsIncompatible reports whether the module's version is a major version >= 2 released
+// before the module adopted Go modules, e.g. 'v20.10.0+incompatible'.
+// Such versions are published under the path without major version suffix.
+func (m *Module) IsIncompatible() bool {
+	return m.Version != nil && m.Version.Metadata() == "incompatible"
+}
+
+// PseudoVersionTime returns the commit time embedded in the pseudo-version.
+func PseudoVersionTime(version *semver.Version) (time.Time, error) {
+	return module.PseudoVersionTime(goVersion(version))
+}
+
+// NewPseudoVersion creates a pseudo-version for an untagged revision
+// of a module with the given major version suffix, e.g. '/v2'.
+func NewPseudoVersion(pathMajor string, t time.Time, rev string) (*semver.Version, error) {
+	var major string
+	if pathMajor != "" {
+		major = module.PathMajorPrefix(pathMajor)
+	}
+	// Go uses 12 characters long commit hash prefix in pseudo-versions.
+	if len(rev) > 12 {
+		rev = rev[:12]
+	}
+	return semver.NewVersion(module.PseudoVersion(major, "", t, rev))
+}
+
+// IsPrerelease reports whether the version is a prerelease, e.g. 'v1.2.0-rc.1'.
+// Pseudo-versions are not considered prereleases, even though they're syntactically prereleases.
+func IsPrerelease(version *semver.Version) bool {
+	return version.Prerelease() != "" && !module.IsPseudoVersion(goVersion(version))
+}
+
+// goVersion returns the version in a format expected by Go tooling, prefixed with 'v'.
+func goVersion(version *semver.Version) string {
+	return "v" + version.String()
+}
+
+// findReplace finds the replace directive which applies to the required module version.
+// Version-specific replacements take precedence over replacements of all versions.
+func findReplace(replaces []*modfile.Replace, required module.Version) *modfile.Replace {
+	var found *modfile.Replace
+	for _, replace := range replaces {
+		if replace.Old.Path != required.Path {
+			continue
+		}
+		if replace.Old.Version == required.Version {
+			return replace
+		}
+		if replace.Old.Version == "" {
+			found = replace
+		}
+	}
+	return found
+}
+
+// applyReplace returns the modules which should be analyzed for the required module.
+// If the module was replaced with a different module, both the replacement and
+// the replaced upstream module are returned.
+func applyReplace(required *Module, replace *modfile.Replace) ([]*Module, error) {
+	if replace == nil {
+		return []*Module{required}, nil
+	}
+	// Local filesystem replacements have no version.
+	if replace.New.Version == "" {
+		required.Replace = &Replace{Kind: ReplaceLocal, Path: replace.New.Path}
+		return []*Module{required}, nil
+	}
+	newVersion, err := semver.NewVersion(replace.New.Version)
+	if err != nil {
+		return nil, err
+	}
+	if replace.New.Path == required.Path {
+		return []*Module{{
+			Path:     required.Path,
+			Version:  newVersion,
+			Indirect: required.Indirect,
+			Replace:  &Replace{Kind: ReplaceVersion, Path: required.Path, Version: required.Version},
+		}}, nil
+	}
+	fork := &Module{
+		Path:     replace.New.Path,
+		Version:  newVersion,
+		Indirect: required.Indirect,
+		Replace:  &Replace{Kind: ReplaceFork, Path: required.Path, Version: required.Version},
+	}
+	required.Replace = &Replace{Kind: ReplaceUpstream, Path: replace.New.Path, Version: required.Version}
+	return []*Module{fork, required}, nil
+}
+
+// markToolModules marks the modules which provide tools declared with 'tool' directives.
+// The tool is provided by the required module with the longest path matching the tool's package path.
+// Tools provided by the main module are ignored.
+// The go command marks modules imported by the main module's packages or tests as direct requirements,
+// such providers are not required only for the tools and are thus not marked.
+// Tools' dependencies can only be detected by analyzing the packages, see [PackageUsage.Tool].
+func markToolModules(modules []*Module, tools []*modfile.Tool) {
+	requiredPath := func(m *Module) string {
+		if m.Replace != nil && m.Replace.Kind == ReplaceFork {
+			return m.Replace.Path
+		}
+		return m.Path
+	}
+	providers := make(map[string]bool, len(tools))
+	for _, tool := range tools {
+		provider := ""
+		for _, m := range modules {
+			path := requiredPath(m)
+			if len(path) > len(provider) && (tool.Path == path || strings.HasPrefix(tool.Path, path+"/")) {
+				provider = path
+			}
+		}
+		if provider != "" {
+			providers[provider] = true
+		}
+	}
+	for _, m := range modules {
+		m.Tool = m.Indirect && providers[requiredPath(m)]
+	}
+}
+
+// ModFileInfo contains module details declared by the authors in the module's go.mod file.
+type ModFileInfo struct {
+	// Deprecated is the deprecation message taken from the '// Deprecated:' module comment.
+	Deprecated string
+	Retract    []*modfile.Retract
+}
+
+// ReadModFileInfo parses a go.mod file of a dependency.
+func ReadModFileInfo(content []byte) (*ModFileInfo, error) {
+	modFile, err := modfile.ParseLax("go.mod", content, nil)
+	if err != nil {
+		return nil, err
+	}
+	info := &ModFileInfo{Retract: modFile.Retract}
+	if modFile.Module != nil {
+		info.Deprecated = modFile.Module.Deprecated
+	}
+	return info, nil
+}
+
+// IsRetracted reports whether the version falls into any of the retracted version intervals.
+func (i *ModFileInfo) IsRetracted(version *semver.Version) bool {
+	v := goVersion(version)
+	for _, retract := range i.Retract {
+		if gosemver.Compare(retract.Low, v) <= 0 && gosemver.Compare(v, retract.High) <= 0 {
+			return true
+		}
+	}
+	return false
 }
 
 type VersionsDiff [3]int64
@@ -46,35 +299,87 @@ func (v VersionsDiff) Add(y VersionsDiff) VersionsDiff {
 	return result
 }
 
-func ReadGoMod(content []byte) (mainModule *Module, modules []*Module, err error) {
+// GoMod contains the details of the analyzed go.mod file.
+type GoMod struct {
+	Main    *Module
+	Modules []*Module
+	// GoVersion is the version declared with 'go' directive, e.g. '1.21'.
+	GoVersion string
+	// Toolchain is the toolchain declared with 'toolchain' directive, e.g. 'go1.21.3'.
+	Toolchain string
+	// Excludes lists the versions excluded with 'exclude' directives.
+	Excludes Excludes
+}
+
+// Excludes lists module versions excluded with 'exclude' directives, keyed by module path.
+type Excludes map[string][]*semver.Version
+
+// IsExcluded reports whether the module version was excluded.
+func (e Excludes) IsExcluded(path string, version *semver.Version) bool {
+	return slices.ContainsFunc(e[path], version.Equal)
+}
+
+// Filter returns the versions of the module which were not excluded.
+func (e Excludes) Filter(path string, versions []*semver.Version) []*semver.Version {
+	if len(e[path]) == 0 {
+		return versions
+	}
+	filtered := make([]*semver.Version, 0, len(versions))
+	for _, version := range versions {
+		if !e.IsExcluded(path, version) {
+			filtered = append(filtered, version)
+		}
+	}
+	return filtered
+}
+
+func ReadGoMod(content []byte) (*GoMod, error) {
 	// Parse the go.mod file.
 	modFile, err := modfile.Parse("go.mod", content, nil)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	modules = make([]*Module, 0, len(modFile.Require))
+	modules := make([]*Module, 0, len(modFile.Require))
 	// List all dependencies, including any replace blocks, from the parsed go.mod file.
 	for _, require := range modFile.Require {
-		// Filter out replaced modules.
-		if slices.ContainsFunc(modFile.Replace, func(replaced *modfile.Replace) bool {
-			return replaced.Old.Path == require.Mod.Path
-		}) {
-			continue
-		}
 		version, err := semver.NewVersion(require.Mod.Version)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
-		modules = append(modules, &Module{
+		replaced, err := applyReplace(&Module{
 			Path:     require.Mod.Path,
 			Version:  version,
 			Indirect: require.Indirect,
-		})
+		}, findReplace(modFile.Replace, require.Mod))
+		if err != nil {
+			return nil, err
+		}
+		modules = append(modules, replaced...)
 	}
 	if modFile.Module == nil {
-		return nil, nil, fmt.Errorf("go.mod file does not contain module declaration")
+		return nil, fmt.Errorf("go.mod file does not contain module declaration")
+	}
+	markToolModules(modules, modFile.Tool)
+	goMod := &GoMod{
+		Main:    &Module{Path: modFile.Module.Mod.Path},
+		Modules: modules,
+	}
+	for _, exclude := range modFile.Exclude {
+		version, err := semver.NewVersion(exclude.Mod.Version)
+		if err != nil {
+			return nil, err
+		}
+		if goMod.Excludes == nil {
+			goMod.Excludes = make(Excludes)
+		}
+		goMod.Excludes[exclude.Mod.Path] = append(goMod.Excludes[exclude.Mod.Path], version)
+	}
+	if modFile.Go != nil {
+		goMod.GoVersion = modFile.Go.Version
+	}
+	if modFile.Toolchain != nil {
+		goMod.Toolchain = modFile.Toolchain.Name
 	}
-	mainModule = &Module{Path: modFile.Module.Mod.Path}
-	return mainModule, modules, nil
+	return goMod, nil
 }
@@ -0,0 +1,153 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadGoMod_Replace(t *testing.T) {
+	const goMod = `module github.com/nieomylnieja/test
+
+go 1.21
+
+require (
+	github.com/a/version v1.0.0
+	github.com/b/fork v1.1.0
+	github.com/c/local v1.2.0 // indirect
+	github.com/d/specific v1.3.0
+	github.com/e/mismatch v1.4.0
+)
+
+replace (
+	github.com/a/version => github.com/a/version v1.0.1
+	github.com/b/fork => github.com/someone/fork v1.5.0
+	github.com/c/local => ../local
+	github.com/d/specific => github.com/d/specific v1.3.1
+	github.com/d/specific v1.3.0 => github.com/d/specific v1.3.2
+	github.com/e/mismatch v1.0.0 => github.com/e/mismatch v1.0.1
+)
+`
+	goModFile, err := ReadGoMod([]byte(goMod))
+	require.NoError(t, err)
+
+	expected := []*Module{
+		{
+			Path:    "github.com/a/version",
+			Version: semver.MustParse("v1.0.1"),
+			Replace: &Replace{
+				Kind:    ReplaceVersion,
+				Path:    "github.com/a/version",
+				Version: semver.MustParse("v1.0.0"),
+			},
+		},
+		{
+			Path:    "github.com/someone/fork",
+			Version: semver.MustParse("v1.5.0"),
+			Replace: &Replace{
+				Kind:    ReplaceFork,
+				Path:    "github.com/b/fork",
+				Version: semver.MustParse("v1.1.0"),
+			},
+		},
+		{
+			Path:    "github.com/b/fork",
+			Version: semver.MustParse("v1.1.0"),
+			Replace: &Replace{
+				Kind:    ReplaceUpstream,
+				Path:    "github.com/someone/fork",
+				Version: semver.MustParse("v1.1.0"),
+			},
+		},
+		{
+			Path:     "github.com/c/local",
+			Version:  semver.MustParse("v1.2.0"),
+			Indirect: true,
+			Replace:  &Replace{Kind: ReplaceLocal, Path: "../local"},
+		},
+		{
+			Path:    "github.com/d/specific",
+			Version: semver.MustParse("v1.3.2"),
+			Replace: &Replace{
+				Kind:    ReplaceVersion,
+				Path:    "github.com/d/specific",
+				Version: semver.MustParse("v1.3.0"),
+			},
+		},
+		{
+			Path:    "github.com/e/mismatch",
+			Version: semver.MustParse("v1.4.0"),
+		},
+	}
+	assert.Equal(t, expected, goModFile.Modules)
+}
+
+func TestReadGoMod_Exclude(t *testing.T) {
+	const goMod = `module github.com/nieomylnieja/test
+
+go 1.21
+
+require github.com/a/b v1.0.0
+
+exclude (
+	github.com/a/b v1.1.0
+	github.com/a/b v1.2.0
+	github.com/c/d v0.1.0
+)
+`
+	goModFile, err := ReadGoMod([]byte(goMod))
+	require.NoError(t, err)
+
+	excludes := goModFile.Excludes
+	assert.True(t, excludes.IsExcluded("github.com/a/b", semver.MustParse("v1.2.0")))
+	assert.True(t, excludes.IsExcluded("github.com/c/d", semver.MustParse("v0.1.0")))
+	assert.False(t, excludes.IsExcluded("github.com/a/b", semver.MustParse("v1.0.0")))
+	assert.False(t, excludes.IsExcluded("github.com/c/d", semver.MustParse("v1.1.0")))
+	assert.Equal(t,
+		[]*semver.Version{semver.MustParse("v1.0.0"), semver.MustParse("v1.3.0")},
+		excludes.Filter("github.com/a/b", []*semver.Version{
+			semver.MustParse("v1.0.0"),
+			semver.MustParse("v1.1.0"),
+			semver.MustParse("v1.2.0"),
+			semver.MustParse("v1.3.0"),
+		}))
+}
+
+func TestReadGoMod_Tool(t *testing.T) {
+	const goMod = `module github.com/nieomylnieja/test
+
+go 1.24
+
+require (
+	github.com/a/b v1.0.0
+	github.com/a/b/v2 v2.0.0 // indirect
+	github.com/c/d v0.1.0
+	github.com/e/f v0.2.0 // indirect
+)
+
+replace github.com/e/f => github.com/g/h v0.3.0
+
+tool (
+	github.com/a/b/v2/cmd/b
+	github.com/c/d/cmd/d
+	github.com/e/f
+)
+`
+	goModFile, err := ReadGoMod([]byte(goMod))
+	require.NoError(t, err)
+
+	tools := make(map[string]bool)
+	for _, m := range goModFile.Modules {
+		tools[m.Path] = m.Tool
+	}
+	assert.Equal(t, map[string]bool{
+		"github.com/a/b":    false,
+		"github.com/a/b/v2": true,
+		// Required directly, it's imported by the project's packages.
+		"github.com/c/d": false,
+		"github.com/e/f": true,
+		"github.com/g/h": true,
+	}, tools)
+}
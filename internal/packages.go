@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/mod/modfile"
+)
+
+// PackageUsage describes how the module's packages are used by the analyzed project.
+type PackageUsage struct {
+	// Packages is the number of the module's packages imported, directly or transitively, by the project.
+	// If the module is only used by tests, it's the number of packages imported by the tests.
+	// If the module is only used by tools, it's the number of packages imported by the tools.
+	Packages int
+	// TestOnly is set if the module's packages are only imported by the project's tests.
+	TestOnly bool
+	// Tool is set if the module's packages are only imported by the tools declared with 'tool' directives,
+	// that is the module provides a tool or is one of the tools' dependencies.
+	Tool bool
+}
+
+// IsProduction reports whether any of the module's packages are imported by the project's non-test code.
+func (u *PackageUsage) IsProduction() bool {
+	return u != nil && u.Packages > 0 && !u.TestOnly && !u.Tool
+}
+
+// NewGoPackagesLister creates a lister which loads the packages of the project located in dir.
+func NewGoPackagesLister(dir string) *GoPackagesLister {
+	return &GoPackagesLister{dir: dir}
+}
+
+type GoPackagesLister struct {
+	dir string
+}
+
+type goListPackage struct {
+	ImportPath string `json:"ImportPath"`
+	Standard   bool   `json:"Standard"`
+	Module     *struct {
+		Path string `json:"Path"`
+		Main bool   `json:"Main"`
+	} `json:"Module"`
+}
+
+// ListPackageUsage runs 'go list -deps -json ./...' and counts the imported packages of each module.
+// The packages are listed once more with '-test' flag in order to detect modules used only by tests.
+// If the project declares tools, their packages are listed with 'tool' pattern
+// in order to detect modules used only by tools.
+// Modules which are required, but not imported at all, are not listed.
+// The returned map is keyed by module path, as required in go.mod, even if the module was replaced.
+func (g *GoPackagesLister) ListPackageUsage() (map[string]*PackageUsage, error) {
+	production, err := g.countPackages("./...")
+	if err != nil {
+		return nil, err
+	}
+	withTests, err := g.countPackages("-test", "./...")
+	if err != nil {
+		return nil, err
+	}
+	var tools map[string]int
+	hasTools, err := g.hasTools()
+	if err != nil {
+		return nil, err
+	}
+	if hasTools {
+		if tools, err = g.countPackages("tool"); err != nil {
+			return nil, err
+		}
+	}
+	usage := make(map[string]*PackageUsage, len(withTests))
+	for path, count := range production {
+		usage[path] = &PackageUsage{Packages: count}
+	}
+	for path, count := range withTests {
+		if _, ok := usage[path]; !ok {
+			usage[path] = &PackageUsage{Packages: count, TestOnly: true}
+		}
+	}
+	for path, count := range tools {
+		if _, ok := usage[path]; !ok {
+			usage[path] = &PackageUsage{Packages: count, Tool: true}
+		}
+	}
+	return usage, nil
+}
+
+// hasTools reports whether the project's go.mod file declares any tools.
+// The 'tool' pattern is not recognized by the go command prior to Go 1.24, it's not used unless necessary.
+func (g *GoPackagesLister) hasTools() (bool, error) {
+	data, err := os.ReadFile(filepath.Join(g.dir, "go.mod"))
+	if err != nil {
+		return false, errors.Wrap(err, "failed to read project's go.mod file")
+	}
+	modFile, err := modfile.Parse("go.mod", data, nil)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to parse project's go.mod file")
+	}
+	return len(modFile.Tool) > 0, nil
+}
+
+// countPackages counts the imported packages of each module, excluding standard library and main module.
+// The arguments are appended to 'go list -deps -json' command.
+func (g *GoPackagesLister) countPackages(args ...string) (map[string]int, error) {
+	out, err := execCmdInDir(g.dir, "go", append([]string{"list", "-deps", "-json"}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	seen := make(map[string]struct{})
+	dec := json.NewDecoder(out)
+	for {
+		var pkg goListPackage
+		if err = dec.Decode(&pkg); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, errors.Wrap(err, "failed to decode 'go list' output")
+		}
+		if pkg.Standard || pkg.Module == nil || pkg.Module.Main {
+			continue
+		}
+		// With '-test' flag, packages may be listed multiple times, e.g. 'pkg [pkg.test]'.
+		importPath, _, _ := strings.Cut(pkg.ImportPath, " ")
+		if _, ok := seen[importPath]; ok {
+			continue
+		}
+		seen[importPath] = struct{}{}
+		counts[pkg.Module.Path]++
+	}
+	return counts, nil
+}
@@ -0,0 +1,26 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoPackagesLister_ListPackageUsage(t *testing.T) {
+	usage, err := NewGoPackagesLister("testdata/packages").ListPackageUsage()
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]*PackageUsage{
+		// Both packages are imported, one of them transitively, one of them is also imported by the tool.
+		"example.com/lib": {Packages: 2},
+		// Only imported by tests.
+		"example.com/testlib": {Packages: 1, TestOnly: true},
+		// Tool and its dependency, only imported by the tool.
+		"example.com/tool":    {Packages: 1, Tool: true},
+		"example.com/tooldep": {Packages: 1, Tool: true},
+	}, usage)
+	assert.True(t, usage["example.com/lib"].IsProduction())
+	assert.False(t, usage["example.com/testlib"].IsProduction())
+	assert.False(t, usage["example.com/tool"].IsProduction())
+}
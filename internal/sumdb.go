@@ -0,0 +1,263 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+	"golang.org/x/mod/sumdb"
+	"golang.org/x/mod/sumdb/note"
+)
+
+// DefaultGoSumDB is the checksum database used by the go command, unless GOSUMDB is set.
+const DefaultGoSumDB = "sum.golang.org"
+
+// knownGoSumDBKeys lists the verifier keys of the checksum databases which can be referred to by name only.
+var knownGoSumDBKeys = map[string]string{
+	"sum.golang.org":       "sum.golang.org+033de0ae+Ac4zctda0e5eza+HJyk9SxEdh+s3Ux18htTTAD8OuAn8",
+	"sum.golang.google.cn": "sum.golang.org+033de0ae+Ac4zctda0e5eza+HJyk9SxEdh+s3Ux18htTTAD8OuAn8",
+}
+
+// NewSumDBFromEnv creates [SumDB] for the given checksum database, or GOSUMDB environment variable if it's empty.
+// Modules which should not be looked up are read from GONOSUMDB or GOPRIVATE environment variables.
+// It returns nil if the checksum database is 'off'.
+func NewSumDBFromEnv(client *http.Client, gosumdb string) (*SumDB, error) {
+	if gosumdb == "" {
+		gosumdb = os.Getenv("GOSUMDB")
+	}
+	if gosumdb == "" {
+		gosumdb = DefaultGoSumDB
+	}
+	if gosumdb == "off" {
+		return nil, nil
+	}
+	noSumDB := os.Getenv("GONOSUMDB")
+	if noSumDB == "" {
+		noSumDB = os.Getenv("GOPRIVATE")
+	}
+	return NewSumDB(client, gosumdb, noSumDB)
+}
+
+// NewSumDB creates [SumDB] for the given checksum database, which follows the GOSUMDB syntax:
+// either a known database name, e.g. sum.golang.org, or a verifier key optionally followed by the database URL.
+// If the URL is not provided, https://<name> is used.
+// Modules matching noSumDB patterns, which follow the GONOSUMDB syntax, are not looked up.
+// If client is nil, [DefaultHTTPConfig] is used.
+func NewSumDB(client *http.Client, gosumdb, noSumDB string) (*SumDB, error) {
+	fields := strings.Fields(gosumdb)
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, errors.Errorf("invalid GOSUMDB: %q", gosumdb)
+	}
+	key, known := knownGoSumDBKeys[fields[0]]
+	if !known {
+		key = fields[0]
+	}
+	verifier, err := note.NewVerifier(key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid GOSUMDB key: %q", fields[0])
+	}
+	rawURL := "https://" + verifier.Name()
+	switch {
+	case len(fields) == 2:
+		rawURL = fields[1]
+	case known:
+		rawURL = "https://" + fields[0]
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse GOSUMDB url")
+	}
+	ops := &sumDBClientOps{
+		http:   httpClientOrDefault(client),
+		url:    u,
+		key:    []byte(key),
+		config: make(map[string][]byte),
+		cache:  make(map[string][]byte),
+	}
+	sumDBClient := sumdb.NewClient(ops)
+	sumDBClient.SetGONOSUMDB(noSumDB)
+	return &SumDB{name: verifier.Name(), client: sumDBClient, ops: ops}, nil
+}
+
+// SumDB looks up go.mod files' hashes in the checksum database.
+// The signed tree is only kept in memory, so each [SumDB] starts verifying the database from scratch.
+type SumDB struct {
+	name   string
+	client *sumdb.Client
+	ops    *sumDBClientOps
+}
+
+func (s *SumDB) String() string {
+	return s.name
+}
+
+// ModFileHash returns the hash of the module's go.mod file, as recorded in the checksum database.
+// If the module matches GONOSUMDB patterns, false is returned.
+func (s *SumDB) ModFileHash(path string, version *semver.Version) (string, bool, error) {
+	modVersion := "v" + version.String() + "/go.mod"
+	lines, err := s.client.Lookup(path, modVersion)
+	if err != nil {
+		if errors.Is(err, sumdb.ErrGONOSUMDB) {
+			return "", false, nil
+		}
+		if msg := s.ops.securityError(); msg != "" {
+			return "", false, errors.Errorf("%s security error: %s", s.name, msg)
+		}
+		return "", false, errors.Wrapf(err, "%s lookup failed", s.name)
+	}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[0] == path && fields[1] == modVersion {
+			return fields[2], true, nil
+		}
+	}
+	return "", false, errors.Errorf("%s did not return the hash of %s@%s", s.name, path, modVersion)
+}
+
+// sumDBClientOps implements [sumdb.ClientOps], keeping the configuration and cache in memory.
+type sumDBClientOps struct {
+	http   *http.Client
+	url    *url.URL
+	key    []byte
+	mu     sync.Mutex
+	config map[string][]byte
+	cache  map[string][]byte
+	secErr string
+}
+
+func (o *sumDBClientOps) ReadRemote(path string) ([]byte, error) {
+	u := o.url.JoinPath(path).String()
+	resp, err := o.http.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.WithStack(&ResponseError{
+			Method:     http.MethodGet,
+			URL:        u,
+			StatusCode: resp.StatusCode,
+			Body:       string(data),
+		})
+	}
+	return data, nil
+}
+
+func (o *sumDBClientOps) ReadConfig(file string) ([]byte, error) {
+	if file == "key" {
+		return o.key, nil
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.config[file], nil
+}
+
+func (o *sumDBClientOps) WriteConfig(file string, old, new []byte) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !bytes.Equal(o.config[file], old) {
+		return sumdb.ErrWriteConflict
+	}
+	o.config[file] = new
+	return nil
+}
+
+func (o *sumDBClientOps) ReadCache(file string) ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	data, ok := o.cache[file]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return data, nil
+}
+
+func (o *sumDBClientOps) WriteCache(file string, data []byte) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.cache[file] = data
+}
+
+func (o *sumDBClientOps) Log(string) {}
+
+// SecurityError records the message, [sumdb.Client] returns [sumdb.ErrSecurity] from the operation.
+func (o *sumDBClientOps) SecurityError(msg string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.secErr = msg
+}
+
+func (o *sumDBClientOps) securityError() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.secErr
+}
+
+// ChecksumMismatchError is returned by [ModFileVerifier] if the go.mod file does not match the expected hash.
+type ChecksumMismatchError struct {
+	Path    string
+	Version *semver.Version
+	// Source is either 'go.sum' or the checksum database name.
+	Source   string
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("%s@v%s: go.mod checksum mismatch, %s: %s, downloaded: %s",
+		e.Path, e.Version, e.Source, e.Expected, e.Actual)
+}
+
+// ModFileVerifier verifies go.mod files against go.sum and/or the checksum database.
+type ModFileVerifier struct {
+	// GoSum is optional, modules which are not listed are only verified with SumDB.
+	GoSum GoSum
+	// SumDB is optional, if nil, only GoSum is used.
+	SumDB *SumDB
+}
+
+// Verify returns [ChecksumMismatchError] if the go.mod file contents don't match the expected hash.
+// Other errors are returned if the expected hash could not be retrieved.
+func (v *ModFileVerifier) Verify(path string, version *semver.Version, data []byte) error {
+	actual, err := HashModFile(data)
+	if err != nil {
+		return err
+	}
+	if expected, ok := v.GoSum.ModFileHash(path, version); ok && expected != actual {
+		return &ChecksumMismatchError{
+			Path:     path,
+			Version:  version,
+			Source:   "go.sum",
+			Expected: expected,
+			Actual:   actual,
+		}
+	}
+	if v.SumDB == nil {
+		return nil
+	}
+	expected, ok, err := v.SumDB.ModFileHash(path, version)
+	if err != nil {
+		return err
+	}
+	if ok && expected != actual {
+		return &ChecksumMismatchError{
+			Path:     path,
+			Version:  version,
+			Source:   v.SumDB.String(),
+			Expected: expected,
+			Actual:   actual,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"crypto/rand"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/mod/sumdb"
+	"golang.org/x/mod/sumdb/note"
+)
+
+// newTestSumDB starts a local checksum database which serves the hashes listed in goSum.
+func newTestSumDB(t *testing.T, goSum string) (gosumdb string) {
+	t.Helper()
+	signer, verifier, err := note.GenerateKey(rand.Reader, "sum.test")
+	require.NoError(t, err)
+	lines, err := ReadGoSum([]byte(goSum))
+	require.NoError(t, err)
+	ops := sumdb.NewTestServer(signer, func(path, version string) ([]byte, error) {
+		hash, ok := lines[path+"@"+version]
+		if !ok {
+			// Reported with 404 status code, which is not retried.
+			return nil, os.ErrNotExist
+		}
+		return []byte(path + " " + version + "/go.mod " + hash + "\n"), nil
+	})
+	server := httptest.NewServer(sumdb.NewServer(ops))
+	t.Cleanup(server.Close)
+	return verifier + " " + server.URL
+}
+
+func TestNewSumDB(t *testing.T) {
+	sumDB, err := NewSumDB(nil, DefaultGoSumDB, "")
+	require.NoError(t, err)
+	assert.Equal(t, "sum.golang.org", sumDB.String())
+	assert.Equal(t, "https://sum.golang.org", sumDB.ops.url.String())
+
+	sumDB, err = NewSumDB(nil, "sum.golang.google.cn", "")
+	require.NoError(t, err)
+	assert.Equal(t, "sum.golang.org", sumDB.String())
+	assert.Equal(t, "https://sum.golang.google.cn", sumDB.ops.url.String())
+
+	_, err = NewSumDB(nil, "sum.example.com", "")
+	assert.ErrorContains(t, err, `invalid GOSUMDB key: "sum.example.com"`)
+	_, err = NewSumDB(nil, "", "")
+	assert.EqualError(t, err, `invalid GOSUMDB: ""`)
+
+	sumDB, err = NewSumDBFromEnv(nil, "off")
+	require.NoError(t, err)
+	assert.Nil(t, sumDB)
+}
+
+func TestModFileVerifier(t *testing.T) {
+	goSum, err := ReadGoSum([]byte(testGoSum))
+	require.NoError(t, err)
+	sumDB, err := NewSumDB(nil, newTestSumDB(t, testGoSum), "github.com/private/*")
+	require.NoError(t, err)
+	path, version := "github.com/pkg/errors", semver.MustParse("v0.9.1")
+	modFile := []byte("module github.com/pkg/errors\n")
+	tamperedModFile := []byte("module github.com/pkg/errors\n\nretract v0.9.1\n")
+
+	t.Run("go.sum and checksum database", func(t *testing.T) {
+		verifier := &ModFileVerifier{GoSum: goSum, SumDB: sumDB}
+		require.NoError(t, verifier.Verify(path, version, modFile))
+
+		err := verifier.Verify(path, version, tamperedModFile)
+		var mismatch *ChecksumMismatchError
+		require.ErrorAs(t, err, &mismatch)
+		assert.Equal(t, "go.sum", mismatch.Source)
+		assert.Equal(t, "h1:bwawxfHBFNV+L2hUp1rHADufV3IMtnDRdf1r5NINEl0=", mismatch.Expected)
+	})
+	t.Run("checksum database only", func(t *testing.T) {
+		verifier := &ModFileVerifier{SumDB: sumDB}
+		require.NoError(t, verifier.Verify(path, version, modFile))
+
+		err := verifier.Verify(path, version, tamperedModFile)
+		var mismatch *ChecksumMismatchError
+		require.ErrorAs(t, err, &mismatch)
+		assert.Equal(t, "sum.test", mismatch.Source)
+		assert.ErrorContains(t, err, "github.com/pkg/errors@v0.9.1: go.mod checksum mismatch, sum.test: h1:bwawx")
+	})
+	t.Run("module not in checksum database", func(t *testing.T) {
+		verifier := &ModFileVerifier{SumDB: sumDB}
+		err := verifier.Verify("github.com/c/d", version, modFile)
+		require.Error(t, err)
+		assert.NotErrorAs(t, err, new(*ChecksumMismatchError))
+	})
+	t.Run("GONOSUMDB module", func(t *testing.T) {
+		verifier := &ModFileVerifier{SumDB: sumDB}
+		assert.NoError(t, verifier.Verify("github.com/private/repo", version, modFile))
+	})
+}
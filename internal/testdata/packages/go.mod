@@ -0,0 +1,19 @@
+module example.com/app
+
+go 1.24
+
+require (
+	example.com/lib v1.0.0
+	example.com/testlib v1.0.0
+	example.com/tool v1.0.0 // indirect
+	example.com/tooldep v1.0.0 // indirect
+)
+
+replace (
+	example.com/lib => ./lib
+	example.com/testlib => ./testlib
+	example.com/tool => ./tool
+	example.com/tooldep => ./tooldep
+)
+
+tool example.com/tool/cmd/tool
@@ -0,0 +1,5 @@
+package a
+
+import "example.com/lib/b"
+
+func Run() { b.Run() }
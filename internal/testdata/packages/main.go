@@ -0,0 +1,5 @@
+package main
+
+import "example.com/lib/a"
+
+func main() { a.Run() }
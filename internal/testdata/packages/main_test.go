@@ -0,0 +1,9 @@
+package main
+
+import (
+	"testing"
+
+	"example.com/testlib"
+)
+
+func TestApp(t *testing.T) { testlib.Check(t) }
@@ -0,0 +1,3 @@
+module example.com/testlib
+
+go 1.23
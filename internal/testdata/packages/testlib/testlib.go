@@ -0,0 +1,5 @@
+package testlib
+
+import "testing"
+
+func Check(*testing.T) {}
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"example.com/lib/b"
+	"example.com/tooldep"
+)
+
+func main() {
+	b.Run()
+	tooldep.Run()
+}
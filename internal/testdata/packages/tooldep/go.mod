@@ -0,0 +1,3 @@
+module example.com/tooldep
+
+go 1.24
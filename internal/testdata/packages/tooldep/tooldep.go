@@ -0,0 +1,3 @@
+package tooldep
+
+func Run() {}
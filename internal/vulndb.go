@@ -0,0 +1,214 @@
+package internal
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+	gosemver "golang.org/x/mod/semver"
+)
+
+// NewVulnDB creates a client for an offline vulnerability database.
+// The source is either a directory or a zip archive following the layout served by vuln.go.dev,
+// with 'index/modules.json' file and OSV entries stored under 'ID' directory.
+// The database is loaded lazily, on the first lookup.
+func NewVulnDB(source string) *VulnDB {
+	return &VulnDB{source: source}
+}
+
+type VulnDB struct {
+	source string
+
+	once    sync.Once
+	fsys    fs.FS
+	modules map[string][]string
+	loadErr error
+
+	mu      sync.Mutex
+	entries map[string]*osvEntry
+}
+
+// Vulnerability is a known vulnerability affecting a module version.
+type Vulnerability struct {
+	ID      string
+	Summary string
+	Aliases []string
+	// Fixed is the first version which is no longer affected, nil if there's no fix yet.
+	Fixed *semver.Version
+}
+
+// osvEntry is a subset of OSV schema, see https://ossf.github.io/osv-schema.
+type osvEntry struct {
+	ID       string        `json:"id"`
+	Summary  string        `json:"summary"`
+	Aliases  []string      `json:"aliases"`
+	Affected []osvAffected `json:"affected"`
+}
+
+type osvAffected struct {
+	Package struct {
+		Name      string `json:"name"`
+		Ecosystem string `json:"ecosystem"`
+	} `json:"package"`
+	Ranges []osvRange `json:"ranges"`
+}
+
+type osvRange struct {
+	Type   string     `json:"type"`
+	Events []osvEvent `json:"events"`
+}
+
+type osvEvent struct {
+	Introduced string `json:"introduced,omitempty"`
+	Fixed      string `json:"fixed,omitempty"`
+}
+
+type vulnDBModule struct {
+	Path  string `json:"path"`
+	Vulns []struct {
+		ID string `json:"id"`
+	} `json:"vulns"`
+}
+
+// GetVulnerabilities lists known vulnerabilities affecting the module version.
+func (v *VulnDB) GetVulnerabilities(modulePath string, version *semver.Version) ([]Vulnerability, error) {
+	v.once.Do(func() { v.loadErr = v.load() })
+	if v.loadErr != nil {
+		return nil, v.loadErr
+	}
+	var vulns []Vulnerability
+	for _, id := range v.modules[modulePath] {
+		entry, err := v.getEntry(id)
+		if err != nil {
+			return nil, err
+		}
+		affected, fixed := entry.affects(modulePath, goVersion(version))
+		if !affected {
+			continue
+		}
+		vuln := Vulnerability{
+			ID:      entry.ID,
+			Summary: entry.Summary,
+			Aliases: entry.Aliases,
+		}
+		if fixed != "" {
+			if vuln.Fixed, err = semver.NewVersion(fixed); err != nil {
+				return nil, errors.Wrapf(err, "invalid fixed version in %s", entry.ID)
+			}
+		}
+		vulns = append(vulns, vuln)
+	}
+	return vulns, nil
+}
+
+func (v *VulnDB) load() error {
+	info, err := os.Stat(v.source)
+	if err != nil {
+		return errors.Wrap(err, "failed to open vulnerability database")
+	}
+	if info.IsDir() {
+		v.fsys = os.DirFS(v.source)
+	} else {
+		// The archive is read into memory, so that no file handle outlives the load,
+		// OSV entries are still decoded lazily.
+		data, err := os.ReadFile(v.source)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read vulnerability database archive %s", v.source)
+		}
+		reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return errors.Wrapf(err, "failed to open vulnerability database archive %s", v.source)
+		}
+		v.fsys = reader
+	}
+	data, err := fs.ReadFile(v.fsys, "index/modules.json")
+	if err != nil {
+		return errors.Wrap(err, "failed to read vulnerability database modules index")
+	}
+	var modules []vulnDBModule
+	if err = json.Unmarshal(data, &modules); err != nil {
+		return errors.Wrap(err, "failed to decode vulnerability database modules index")
+	}
+	v.modules = make(map[string][]string, len(modules))
+	for _, module := range modules {
+		for _, vuln := range module.Vulns {
+			v.modules[module.Path] = append(v.modules[module.Path], vuln.ID)
+		}
+	}
+	v.entries = make(map[string]*osvEntry)
+	return nil
+}
+
+func (v *VulnDB) getEntry(id string) (*osvEntry, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if entry, ok := v.entries[id]; ok {
+		return entry, nil
+	}
+	data, err := fs.ReadFile(v.fsys, path.Join("ID", id+".json"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read vulnerability %s", id)
+	}
+	var entry osvEntry
+	if err = json.Unmarshal(data, &entry); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode vulnerability %s", id)
+	}
+	v.entries[id] = &entry
+	return &entry, nil
+}
+
+// affects reports whether the module version falls into any of the affected ranges.
+// If it does, the version which fixed the vulnerability in this range is returned, if any.
+func (e *osvEntry) affects(modulePath, version string) (affected bool, fixed string) {
+	for _, a := range e.Affected {
+		if a.Package.Name != modulePath {
+			continue
+		}
+		for _, r := range a.Ranges {
+			if r.Type != "SEMVER" {
+				continue
+			}
+			if affected, fixed = r.affects(version); affected {
+				return affected, fixed
+			}
+		}
+	}
+	return false, ""
+}
+
+// affects walks through the range's introduced and fixed events.
+// Events are expected to be sorted, as is the case for vuln.go.dev entries.
+func (r osvRange) affects(version string) (affected bool, fixed string) {
+	introduced := ""
+	for _, event := range r.Events {
+		switch {
+		case event.Introduced != "":
+			introduced = osvVersion(event.Introduced)
+		case event.Fixed != "":
+			fixedVersion := osvVersion(event.Fixed)
+			if introduced != "" &&
+				gosemver.Compare(introduced, version) <= 0 &&
+				gosemver.Compare(version, fixedVersion) < 0 {
+				return true, fixedVersion
+			}
+			introduced = ""
+		}
+	}
+	return introduced != "" && gosemver.Compare(introduced, version) <= 0, ""
+}
+
+// osvVersion converts OSV SEMVER version into Go's canonical form, prefixed with 'v'.
+// Introduced version '0' means the very first version.
+func osvVersion(version string) string {
+	if version == "0" {
+		return "v0.0.0-0"
+	}
+	return "v" + strings.TrimPrefix(version, "v")
+}
@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testVulnDBFiles = map[string]string{
+	"index/modules.json": `[
+  {"path": "github.com/a/b", "vulns": [{"id": "GO-0000-0001"}, {"id": "GO-0000-0002"}]}
+]`,
+	"ID/GO-0000-0001.json": `{
+  "id": "GO-0000-0001",
+  "summary": "Panic in github.com/a/b",
+  "aliases": ["CVE-0000-0001"],
+  "affected": [{
+    "package": {"name": "github.com/a/b", "ecosystem": "Go"},
+    "ranges": [{"type": "SEMVER", "events": [
+      {"introduced": "0"}, {"fixed": "1.2.0"},
+      {"introduced": "1.3.0"}, {"fixed": "1.3.2"}
+    ]}]
+  }]
+}`,
+	"ID/GO-0000-0002.json": `{
+  "id": "GO-0000-0002",
+  "summary": "Unfixed issue in github.com/a/b",
+  "affected": [{
+    "package": {"name": "github.com/a/b", "ecosystem": "Go"},
+    "ranges": [{"type": "SEMVER", "events": [{"introduced": "1.3.1"}]}]
+  }]
+}`,
+}
+
+func TestVulnDB_GetVulnerabilities(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range testVulnDBFiles {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	zipPath := filepath.Join(t.TempDir(), "vulndb.zip")
+	f, err := os.Create(zipPath)
+	require.NoError(t, err)
+	zw := zip.NewWriter(f)
+	for name, content := range testVulnDBFiles {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	require.NoError(t, f.Close())
+
+	tests := map[string]struct {
+		Path     string
+		Version  string
+		Expected []string
+		Fixed    []string
+	}{
+		"first range": {
+			Path:     "github.com/a/b",
+			Version:  "v1.1.0",
+			Expected: []string{"GO-0000-0001"},
+			Fixed:    []string{"1.2.0"},
+		},
+		"between ranges": {
+			Path:    "github.com/a/b",
+			Version: "v1.2.5",
+		},
+		"second range and unfixed": {
+			Path:     "github.com/a/b",
+			Version:  "v1.3.1",
+			Expected: []string{"GO-0000-0001", "GO-0000-0002"},
+			Fixed:    []string{"1.3.2", ""},
+		},
+		"pseudo-version": {
+			Path:     "github.com/a/b",
+			Version:  "v0.0.0-20230101120000-abcdef123456",
+			Expected: []string{"GO-0000-0001"},
+			Fixed:    []string{"1.2.0"},
+		},
+		"unknown module": {
+			Path:    "github.com/c/d",
+			Version: "v1.0.0",
+		},
+	}
+	for source, db := range map[string]*VulnDB{
+		"directory": NewVulnDB(dir),
+		"zip":       NewVulnDB(zipPath),
+	} {
+		for name, test := range tests {
+			t.Run(source+": "+name, func(t *testing.T) {
+				vulns, err := db.GetVulnerabilities(test.Path, semver.MustParse(test.Version))
+				require.NoError(t, err)
+				require.Len(t, vulns, len(test.Expected))
+				for i, vuln := range vulns {
+					assert.Equal(t, test.Expected[i], vuln.ID)
+					if test.Fixed[i] == "" {
+						assert.Nil(t, vuln.Fixed)
+					} else {
+						assert.Equal(t, test.Fixed[i], vuln.Fixed.String())
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestVulnDB_GetVulnerabilities_MissingDB(t *testing.T) {
+	_, err := NewVulnDB(filepath.Join(t.TempDir(), "missing")).
+		GetVulnerabilities("github.com/a/b", semver.MustParse("v1.0.0"))
+	require.ErrorContains(t, err, "failed to open vulnerability database")
+}
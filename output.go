@@ -12,12 +12,34 @@ import (
 )
 
 type Summary struct {
-	Modules  []*internal.Module
-	Main     *internal.Module
-	releases bool
-	versions bool
+	Modules   []*internal.Module
+	Main      *internal.Module
+	Toolchain *ToolchainSummary
+	// Tools is only set if modules providing tools are reported separately.
+	Tools      *ToolsSummary
+	releases   bool
+	versions   bool
+	retracted  bool
+	deprecated bool
+	vulns      bool
+	activity   bool
+	usage      bool
+	migrations bool
+	checksums  bool
+	// aggregation describes the aggregation of the main module's metrics, it's empty for the default sum.
+	aggregation string
 }
 
+// ToolsSummary contains the modules providing tools, declared with 'tool' directives.
+type ToolsSummary struct {
+	// Main holds the aggregated metrics of the tools, its path is always 'tools'.
+	Main    *internal.Module
+	Modules []*internal.Module
+}
+
+// toolsSectionName is the name of the separate tools section.
+const toolsSectionName = "tools"
+
 type Output interface {
 	Send(summary Summary) error
 }
@@ -25,7 +47,7 @@ type Output interface {
 type TableOutput struct{}
 
 func (p TableOutput) Send(summary Summary) error {
-	data := convertSummaryToTable(summary)
+	data := convertSummaryToTable(summary, false)
 	columnWidths := make([]int, len(data[0]))
 	for _, row := range data {
 		for i, cell := range row {
@@ -51,12 +73,61 @@ type CSVOutput struct{}
 
 func (p CSVOutput) Send(summary Summary) error {
 	w := csv.NewWriter(os.Stdout)
-	return w.WriteAll(convertSummaryToTable(summary))
+	return w.WriteAll(convertSummaryToTable(summary, true))
+}
+
+const (
+	timeFmt            = time.DateOnly
+	pseudoVersionLabel = "(pseudo)"
+	// unsupportedToolchainLabel marks Go versions outside of the two-release support window.
+	unsupportedToolchainLabel = "(unsupported)"
+	// toolLabel marks modules which provide tools.
+	toolLabel = "(tool)"
+)
+
+// replaceLabel describes the replace directive affecting the module.
+func replaceLabel(m *internal.Module) string {
+	if m.Replace == nil {
+		return ""
+	}
+	switch m.Replace.Kind {
+	case internal.ReplaceVersion:
+		return "(replaces v" + m.Replace.Version.String() + ")"
+	case internal.ReplaceFork:
+		return "(replaces " + m.Replace.Path + ")"
+	case internal.ReplaceUpstream, internal.ReplaceLocal:
+		return "(replaced by " + m.Replace.Path + ")"
+	default:
+		return ""
+	}
+}
+
+// durationToDays converts the duration to the number of full days.
+func durationToDays(d time.Duration) int {
+	return int(d.Hours() / 24)
+}
+
+// formatTime formats the module's time, local replacements have no time.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(timeFmt)
 }
 
-const timeFmt = time.DateOnly
+type rowKind int
 
-func convertSummaryToTable(summary Summary) [][]string {
+const (
+	rowMain rowKind = iota
+	rowToolchain
+	rowModule
+)
+
+// convertSummaryToTable converts the summary to rows, the first row being the header.
+// If machineReadable is set, pseudo-versions, replacements, tools, aggregation and toolchain support
+// are reported in separate, last columns, instead of being labeled in the version and package columns,
+// so that these columns are machine-readable.
+func convertSummaryToTable(summary Summary, machineReadable bool) [][]string {
 	t := [][]string{
 		{"package", "version", "date", "latest", "latest_date", "libyear"},
 	}
@@ -66,18 +137,57 @@ func convertSummaryToTable(summary Summary) [][]string {
 	if summary.versions {
 		t[0] = append(t[0], "versions")
 	}
-	addRow := func(m *internal.Module) {
+	if summary.retracted {
+		t[0] = append(t[0], "retracted")
+	}
+	if summary.deprecated {
+		t[0] = append(t[0], "deprecated")
+	}
+	if summary.vulns {
+		t[0] = append(t[0], "vulns", "fixed", "fixed_libyear")
+	}
+	if summary.usage {
+		t[0] = append(t[0], "packages", "test_only")
+	}
+	if summary.activity {
+		t[0] = append(t[0], "last_release", "days_since_release", "release_interval_days", "inactive")
+	}
+	if summary.migrations {
+		t[0] = append(t[0], "migration", "migration_version", "migration_date", "migration_libyear")
+	}
+	if summary.checksums {
+		t[0] = append(t[0], "checksum_mismatch")
+	}
+	if machineReadable {
+		t[0] = append(t[0], "pseudo_version", "replace_kind", "replace_path", "replace_version", "tool")
+		if summary.aggregation != "" {
+			t[0] = append(t[0], "aggregation")
+		}
+		if summary.Toolchain != nil {
+			t[0] = append(t[0], "supported")
+		}
+	}
+	addRow := func(m *internal.Module, kind rowKind) {
 		row := []string{
-			m.Path,                 // 0
-			"",                     // 1
-			m.Time.Format(timeFmt), // 2
-			"",                     // 3
-			"",                     // 4
+			m.Path,             // 0
+			"",                 // 1
+			formatTime(m.Time), // 2
+			"",                 // 3
+			"",                 // 4
 			strconv.FormatFloat(m.Libyear, 'f', 2, 64), // 5
 		}
 		if m.Version != nil {
 			row[1] = m.Version.String()
 		}
+		if m.IsPseudoVersion() && !machineReadable {
+			row[1] += " " + pseudoVersionLabel
+		}
+		if label := replaceLabel(m); label != "" && !machineReadable {
+			row[0] += " " + label
+		}
+		if m.Tool && !machineReadable {
+			row[0] += " " + toolLabel
+		}
 		if m.Latest != nil {
 			row[3] = m.Latest.Version.String()
 			row[4] = m.Latest.Time.Format(timeFmt)
@@ -88,11 +198,123 @@ func convertSummaryToTable(summary Summary) [][]string {
 		if summary.versions {
 			row = append(row, m.VersionsDiff.String())
 		}
+		if summary.retracted {
+			if kind == rowMain {
+				row = append(row, "")
+			} else {
+				row = append(row, strconv.FormatBool(m.Retracted))
+			}
+		}
+		if summary.deprecated {
+			row = append(row, m.Deprecated)
+		}
+		switch {
+		case !summary.vulns:
+		case kind == rowToolchain:
+			// Toolchain is not checked for vulnerabilities.
+			row = append(row, "", "", "")
+		default:
+			fixed := ""
+			if m.Fixed != nil {
+				fixed = m.Fixed.Version.String()
+			}
+			row = append(row,
+				strconv.Itoa(len(m.Vulnerabilities)),
+				fixed,
+				strconv.FormatFloat(m.FixedLibyear, 'f', 2, 64))
+		}
+		if summary.usage {
+			if m.Usage != nil {
+				row = append(row, strconv.Itoa(m.Usage.Packages), strconv.FormatBool(m.Usage.TestOnly))
+			} else {
+				row = append(row, "", "")
+			}
+		}
+		if summary.activity {
+			if m.Activity != nil {
+				row = append(row,
+					m.Activity.LastRelease.Format(timeFmt),
+					strconv.Itoa(durationToDays(m.Activity.SinceLastRelease)),
+					strconv.Itoa(durationToDays(m.Activity.MedianReleaseInterval)),
+					strconv.FormatBool(m.Activity.Inactive))
+			} else {
+				row = append(row, "", "", "", "")
+			}
+		}
+		if summary.migrations {
+			if m.Migration != nil {
+				row = append(row,
+					m.Migration.Path,
+					m.Migration.Version.String(),
+					m.Migration.Time.Format(timeFmt),
+					strconv.FormatFloat(m.MigrationLibyear, 'f', 2, 64))
+			} else {
+				row = append(row, "", "", "", "")
+			}
+		}
+		if summary.checksums {
+			// Only the source of the mismatch is reported, hashes are part of the returned error.
+			if m.ChecksumMismatch != nil {
+				row = append(row, m.ChecksumMismatch.Source)
+			} else {
+				row = append(row, "")
+			}
+		}
+		if machineReadable {
+			if kind == rowModule {
+				row = append(row, strconv.FormatBool(m.IsPseudoVersion()))
+			} else {
+				row = append(row, "")
+			}
+			if m.Replace != nil {
+				replaceVersion := ""
+				if m.Replace.Version != nil {
+					replaceVersion = m.Replace.Version.String()
+				}
+				row = append(row, m.Replace.Kind.String(), m.Replace.Path, replaceVersion)
+			} else {
+				row = append(row, "", "", "")
+			}
+			if kind == rowModule {
+				row = append(row, strconv.FormatBool(m.Tool))
+			} else {
+				row = append(row, "")
+			}
+			switch {
+			case summary.aggregation == "":
+			case kind == rowMain:
+				row = append(row, summary.aggregation)
+			default:
+				row = append(row, "")
+			}
+			switch {
+			case summary.Toolchain == nil:
+			case kind == rowToolchain:
+				row = append(row, strconv.FormatBool(summary.Toolchain.Supported))
+			default:
+				row = append(row, "")
+			}
+		}
 		t = append(t, row)
 	}
-	addRow(summary.Main)
+	addRow(summary.Main, rowMain)
+	if summary.aggregation != "" && !machineReadable {
+		t[len(t)-1][0] += " (" + summary.aggregation + ")"
+	}
+	if summary.Toolchain != nil {
+		addRow(summary.Toolchain.Module, rowToolchain)
+		if !summary.Toolchain.Supported && !machineReadable {
+			t[len(t)-1][0] += " " + unsupportedToolchainLabel
+		}
+	}
 	for _, module := range summary.Modules {
-		addRow(module)
+		addRow(module, rowModule)
+	}
+	if summary.Tools != nil {
+		addRow(summary.Tools.Main, rowMain)
+		for _, module := range summary.Tools.Modules {
+			addRow(module, rowModule)
+		}
 	}
 	return t
 }
@@ -100,12 +322,33 @@ func convertSummaryToTable(summary Summary) [][]string {
 type JSONOutput struct{}
 
 type jsonSummaryModel struct {
-	Module   string             `json:"module"`
-	Date     string             `json:"date"`
+	Module          string              `json:"module"`
+	Date            string              `json:"date"`
+	Libyear         float64             `json:"libyear"`
+	Aggregation     string              `json:"aggregation,omitempty"`
+	Vulnerabilities *int                `json:"vulnerabilities,omitempty"`
+	FixedLibyear    *float64            `json:"fixed_libyear,omitempty"`
+	Toolchain       *jsonToolchainModel `json:"toolchain,omitempty"`
+	Packages        []jsonPackageModel  `json:"packages"`
+	Tools           *jsonToolsModel     `json:"tools,omitempty"`
+}
+
+type jsonToolsModel struct {
 	Libyear  float64            `json:"libyear"`
 	Packages []jsonPackageModel `json:"packages"`
 }
 
+type jsonToolchainModel struct {
+	Version       string                 `json:"version"`
+	Date          string                 `json:"date"`
+	LatestVersion string                 `json:"latest_version"`
+	LatestDate    string                 `json:"latest_date"`
+	Libyear       float64                `json:"libyear"`
+	Releases      int                    `json:"releases"`
+	Versions      *internal.VersionsDiff `json:"versions,omitempty"`
+	Supported     bool                   `json:"supported"`
+}
+
 type jsonPackageModel struct {
 	Package       string                 `json:"package"`
 	Version       string                 `json:"version"`
@@ -113,37 +356,183 @@ type jsonPackageModel struct {
 	LatestVersion string                 `json:"latest_version"`
 	LatestDate    string                 `json:"latest_date"`
 	Libyear       float64                `json:"libyear"`
+	PseudoVersion bool                   `json:"pseudo_version,omitempty"`
+	Tool          bool                   `json:"tool,omitempty"`
 	Releases      *int                   `json:"releases,omitempty"`
 	Versions      *internal.VersionsDiff `json:"versions,omitempty"`
+	Retracted     *bool                  `json:"retracted,omitempty"`
+	Deprecated    *string                `json:"deprecated,omitempty"`
+	Replace       *jsonReplaceModel      `json:"replace,omitempty"`
+	// Vulnerabilities is a pointer to a slice, so that an empty list is still reported.
+	Vulnerabilities  *[]jsonVulnerabilityModel  `json:"vulnerabilities,omitempty"`
+	FixedVersion     string                     `json:"fixed_version,omitempty"`
+	FixedLibyear     *float64                   `json:"fixed_libyear,omitempty"`
+	Usage            *jsonUsageModel            `json:"usage,omitempty"`
+	Activity         *jsonActivityModel         `json:"activity,omitempty"`
+	Migration        *jsonMigrationModel        `json:"migration,omitempty"`
+	ChecksumMismatch *jsonChecksumMismatchModel `json:"checksum_mismatch,omitempty"`
+}
+
+type jsonChecksumMismatchModel struct {
+	Version  string `json:"version"`
+	Source   string `json:"source"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+type jsonMigrationModel struct {
+	Package string  `json:"package"`
+	Version string  `json:"version"`
+	Date    string  `json:"date"`
+	Libyear float64 `json:"libyear"`
+}
+
+type jsonUsageModel struct {
+	Packages int  `json:"packages"`
+	TestOnly bool `json:"test_only"`
+}
+
+type jsonActivityModel struct {
+	LastRelease         string `json:"last_release"`
+	DaysSinceRelease    int    `json:"days_since_release"`
+	ReleaseIntervalDays int    `json:"release_interval_days"`
+	Inactive            bool   `json:"inactive"`
+}
+
+type jsonVulnerabilityModel struct {
+	ID      string   `json:"id"`
+	Summary string   `json:"summary,omitempty"`
+	Aliases []string `json:"aliases,omitempty"`
+	Fixed   string   `json:"fixed,omitempty"`
+}
+
+type jsonReplaceModel struct {
+	Kind    string `json:"kind"`
+	Path    string `json:"path"`
+	Version string `json:"version,omitempty"`
 }
 
 func (j JSONOutput) Send(summary Summary) error {
 	model := jsonSummaryModel{
-		Module:   summary.Main.Path,
-		Date:     summary.Main.Time.Format(timeFmt),
-		Libyear:  summary.Main.Libyear,
-		Packages: make([]jsonPackageModel, 0, len(summary.Modules)),
+		Module:      summary.Main.Path,
+		Date:        summary.Main.Time.Format(timeFmt),
+		Libyear:     summary.Main.Libyear,
+		Aggregation: summary.aggregation,
+		Packages:    make([]jsonPackageModel, 0, len(summary.Modules)),
 	}
-	for _, module := range summary.Modules {
-		m := jsonPackageModel{
-			Package:       module.Path,
+	if summary.vulns {
+		model.Vulnerabilities = ptr(len(summary.Main.Vulnerabilities))
+		model.FixedLibyear = ptr(summary.Main.FixedLibyear)
+	}
+	if summary.Toolchain != nil {
+		module := summary.Toolchain.Module
+		model.Toolchain = &jsonToolchainModel{
 			Version:       module.Version.String(),
 			Date:          module.Time.Format(timeFmt),
 			LatestVersion: module.Latest.Version.String(),
 			LatestDate:    module.Latest.Time.Format(timeFmt),
 			Libyear:       module.Libyear,
-		}
-		if summary.releases {
-			m.Releases = ptr(module.ReleasesDiff)
+			Releases:      module.ReleasesDiff,
+			Supported:     summary.Toolchain.Supported,
 		}
 		if summary.versions {
-			m.Versions = &module.VersionsDiff
+			model.Toolchain.Versions = &module.VersionsDiff
+		}
+	}
+	for _, module := range summary.Modules {
+		model.Packages = append(model.Packages, summary.jsonPackage(module))
+	}
+	if summary.Tools != nil {
+		model.Tools = &jsonToolsModel{
+			Libyear:  summary.Tools.Main.Libyear,
+			Packages: make([]jsonPackageModel, 0, len(summary.Tools.Modules)),
+		}
+		for _, module := range summary.Tools.Modules {
+			model.Tools.Packages = append(model.Tools.Packages, summary.jsonPackage(module))
 		}
-		model.Packages = append(model.Packages, m)
 	}
 	enc := json.NewEncoder(os.Stdout)
 	enc.SetIndent("", "  ")
 	return enc.Encode(model)
 }
 
+func (summary Summary) jsonPackage(module *internal.Module) jsonPackageModel {
+	m := jsonPackageModel{
+		Package:       module.Path,
+		Version:       module.Version.String(),
+		Date:          formatTime(module.Time),
+		Libyear:       module.Libyear,
+		PseudoVersion: module.IsPseudoVersion(),
+		Tool:          module.Tool,
+	}
+	if module.Latest != nil {
+		m.LatestVersion = module.Latest.Version.String()
+		m.LatestDate = module.Latest.Time.Format(timeFmt)
+	}
+	if module.Replace != nil {
+		m.Replace = &jsonReplaceModel{
+			Kind: module.Replace.Kind.String(),
+			Path: module.Replace.Path,
+		}
+		if module.Replace.Version != nil {
+			m.Replace.Version = module.Replace.Version.String()
+		}
+	}
+	if summary.releases {
+		m.Releases = ptr(module.ReleasesDiff)
+	}
+	if summary.versions {
+		m.Versions = &module.VersionsDiff
+	}
+	if summary.retracted {
+		m.Retracted = ptr(module.Retracted)
+	}
+	if summary.deprecated {
+		m.Deprecated = ptr(module.Deprecated)
+	}
+	if summary.vulns {
+		vulns := make([]jsonVulnerabilityModel, 0, len(module.Vulnerabilities))
+		for _, vuln := range module.Vulnerabilities {
+			v := jsonVulnerabilityModel{ID: vuln.ID, Summary: vuln.Summary, Aliases: vuln.Aliases}
+			if vuln.Fixed != nil {
+				v.Fixed = vuln.Fixed.String()
+			}
+			vulns = append(vulns, v)
+		}
+		m.Vulnerabilities = &vulns
+		m.FixedLibyear = ptr(module.FixedLibyear)
+		if module.Fixed != nil {
+			m.FixedVersion = module.Fixed.Version.String()
+		}
+	}
+	if summary.usage && module.Usage != nil {
+		m.Usage = &jsonUsageModel{Packages: module.Usage.Packages, TestOnly: module.Usage.TestOnly}
+	}
+	if summary.activity && module.Activity != nil {
+		m.Activity = &jsonActivityModel{
+			LastRelease:         module.Activity.LastRelease.Format(timeFmt),
+			DaysSinceRelease:    durationToDays(module.Activity.SinceLastRelease),
+			ReleaseIntervalDays: durationToDays(module.Activity.MedianReleaseInterval),
+			Inactive:            module.Activity.Inactive,
+		}
+	}
+	if summary.migrations && module.Migration != nil {
+		m.Migration = &jsonMigrationModel{
+			Package: module.Migration.Path,
+			Version: module.Migration.Version.String(),
+			Date:    module.Migration.Time.Format(timeFmt),
+			Libyear: module.MigrationLibyear,
+		}
+	}
+	if summary.checksums && module.ChecksumMismatch != nil {
+		m.ChecksumMismatch = &jsonChecksumMismatchModel{
+			Version:  module.ChecksumMismatch.Version.String(),
+			Source:   module.ChecksumMismatch.Source,
+			Expected: module.ChecksumMismatch.Expected,
+			Actual:   module.ChecksumMismatch.Actual,
+		}
+	}
+	return m
+}
+
 func ptr[T any](v T) *T { return &v }
@@ -0,0 +1,150 @@
+package libyear
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+	"golang.org/x/mod/module"
+
+	"github.com/nieomylnieja/go-libyear/internal"
+)
+
+// PrereleasePolicy defines whether prerelease versions, e.g. 'v1.2.0-rc.1', can be selected as latest.
+// Pseudo-versions are not affected by the policy.
+// Zero value is equivalent to [PrereleaseCurrent].
+type PrereleasePolicy string
+
+const (
+	// PrereleaseStable never selects prereleases as latest,
+	// unless the module has no stable versions at all.
+	PrereleaseStable PrereleasePolicy = "stable"
+	// PrereleaseCurrent only selects prereleases as latest if the current version is a prerelease.
+	PrereleaseCurrent PrereleasePolicy = "current"
+	// PrereleaseAlways selects prereleases as latest, if they're greater than the latest stable version.
+	PrereleaseAlways PrereleasePolicy = "always"
+)
+
+// ParsePrereleasePolicy parses the prerelease policy name.
+func ParsePrereleasePolicy(s string) (PrereleasePolicy, error) {
+	switch policy := PrereleasePolicy(s); policy {
+	case PrereleaseStable, PrereleaseCurrent, PrereleaseAlways:
+		return policy, nil
+	default:
+		return "", errors.Errorf("invalid prerelease policy '%s', expected one of: stable, current, always", s)
+	}
+}
+
+// ParsePrereleaseOverrides parses per-module prerelease policies, each defined as 'pattern=policy'.
+// Patterns are module path prefix globs, as in GOPRIVATE, e.g. 'github.com/nieomylnieja/*=always'.
+func ParsePrereleaseOverrides(overrides []string) (map[string]PrereleasePolicy, error) {
+	policies := make(map[string]PrereleasePolicy, len(overrides))
+	for _, override := range overrides {
+		pattern, name, ok := strings.Cut(override, "=")
+		if !ok || pattern == "" {
+			return nil, errors.Errorf("invalid prerelease policy override '%s', expected 'pattern=policy'", override)
+		}
+		policy, err := ParsePrereleasePolicy(name)
+		if err != nil {
+			return nil, err
+		}
+		policies[pattern] = policy
+	}
+	return policies, nil
+}
+
+// prereleasePolicy returns the prerelease policy of the module.
+// The override with the longest pattern matching the module path takes precedence over the default policy.
+func (c Command) prereleasePolicy(path string) PrereleasePolicy {
+	policy, matched := c.prereleases, ""
+	if policy == "" {
+		policy = PrereleaseCurrent
+	}
+	for pattern, override := range c.prereleaseOverrides {
+		if len(pattern) > len(matched) && module.MatchPrefixPatterns(pattern, path) {
+			policy, matched = override, pattern
+		}
+	}
+	return policy
+}
+
+// prereleasesAllowed reports whether prereleases of the module can be selected as latest.
+// Current version is optional, if it's not provided, it is not considered to be a prerelease.
+func (c Command) prereleasesAllowed(path string, current *semver.Version) bool {
+	switch c.prereleasePolicy(path) {
+	case PrereleaseStable:
+		return false
+	case PrereleaseAlways:
+		return true
+	default:
+		return current != nil && internal.IsPrerelease(current)
+	}
+}
+
+// filterPrereleases removes prereleases from the versions, unless they're allowed for the module.
+// The kept versions are never removed, even if they're prereleases.
+func (c Command) filterPrereleases(
+	path string,
+	current *semver.Version,
+	versions []*semver.Version,
+	kept ...*semver.Version,
+) []*semver.Version {
+	if c.prereleasesAllowed(path, current) {
+		return versions
+	}
+	filtered := make([]*semver.Version, 0, len(versions))
+	for _, version := range versions {
+		if !internal.IsPrerelease(version) || isAnyOf(version, kept) {
+			filtered = append(filtered, version)
+		}
+	}
+	c.trace.filtered("prerelease policy: "+string(c.prereleasePolicy(path)), versions, filtered)
+	return filtered
+}
+
+// applyPrereleasePolicy adjusts the latest version, returned by the modules' source, to the prerelease policy.
+// Sources usually prefer stable versions, unless there are only prereleases.
+// If prereleases are allowed, the greatest prerelease is returned, if it's greater than latest.
+// Otherwise, if latest is a prerelease, the greatest stable version is returned, if there's any.
+func (c Command) applyPrereleasePolicy(
+	repo ModulesRepo,
+	path string,
+	current *semver.Version,
+	latest *internal.Module,
+) (*internal.Module, error) {
+	allowed := c.prereleasesAllowed(path, current)
+	if !allowed && !internal.IsPrerelease(latest.Version) {
+		return latest, nil
+	}
+	versions, err := c.getVersionsForPath(repo, path, false)
+	if err != nil {
+		if err == errNoVersions {
+			return latest, nil
+		}
+		return nil, err
+	}
+	versions = c.filterExcluded(path, versions)
+	sort.Sort(sort.Reverse(semver.Collection(versions)))
+	for _, version := range versions {
+		if allowed && !version.GreaterThan(latest.Version) {
+			break
+		}
+		if !allowed && (internal.IsPrerelease(version) || version.GreaterThan(latest.Version)) {
+			continue
+		}
+		c.trace.printf("prerelease policy %s: selecting v%s instead of v%s",
+			c.prereleasePolicy(path), version, latest.Version)
+		return repo.GetInfo(path, version)
+	}
+	return latest, nil
+}
+
+func isAnyOf(version *semver.Version, versions []*semver.Version) bool {
+	for _, v := range versions {
+		if v != nil && v.Equal(version) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package libyear
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/nieomylnieja/go-libyear/internal"
+	"github.com/nieomylnieja/go-libyear/internal/mocks"
+)
+
+func TestParsePrereleaseOverrides(t *testing.T) {
+	overrides, err := ParsePrereleaseOverrides([]string{
+		"github.com/nieomylnieja/*=always",
+		"github.com/pkg/errors=stable",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]PrereleasePolicy{
+		"github.com/nieomylnieja/*": PrereleaseAlways,
+		"github.com/pkg/errors":     PrereleaseStable,
+	}, overrides)
+
+	for _, input := range []string{"github.com/pkg/errors", "=always", "github.com/pkg/errors=beta"} {
+		_, err = ParsePrereleaseOverrides([]string{input})
+		assert.Error(t, err, input)
+	}
+}
+
+func TestCommand_PrereleasesAllowed(t *testing.T) {
+	cmd := Command{
+		prereleases: PrereleaseStable,
+		prereleaseOverrides: map[string]PrereleasePolicy{
+			"github.com/nieomylnieja":            PrereleaseAlways,
+			"github.com/nieomylnieja/go-libyear": PrereleaseCurrent,
+		},
+	}
+	stable := semver.MustParse("v1.0.0")
+	prerelease := semver.MustParse("v1.1.0-rc.1")
+	pseudo := semver.MustParse("v1.0.1-0.20230101120000-abcdef123456")
+
+	assert.False(t, cmd.prereleasesAllowed("github.com/pkg/errors", prerelease))
+	assert.True(t, cmd.prereleasesAllowed("github.com/nieomylnieja/other", stable))
+	assert.True(t, cmd.prereleasesAllowed("github.com/nieomylnieja/go-libyear/v2", prerelease))
+	assert.False(t, cmd.prereleasesAllowed("github.com/nieomylnieja/go-libyear", stable))
+	assert.False(t, cmd.prereleasesAllowed("github.com/nieomylnieja/go-libyear", pseudo))
+	assert.False(t, cmd.prereleasesAllowed("github.com/nieomylnieja/go-libyear", nil))
+
+	assert.Equal(t,
+		[]*semver.Version{stable, pseudo, prerelease},
+		cmd.filterPrereleases("github.com/pkg/errors", nil,
+			[]*semver.Version{stable, pseudo, semver.MustParse("v1.0.2-beta"), prerelease},
+			prerelease))
+}
+
+func TestCommand_ApplyPrereleasePolicy(t *testing.T) {
+	path := "github.com/nieomylnieja/go-libyear"
+	versions := []*semver.Version{
+		semver.MustParse("v1.0.0"),
+		semver.MustParse("v1.1.0-rc.1"),
+		semver.MustParse("v1.1.0"),
+		semver.MustParse("v1.2.0-rc.1"),
+		semver.MustParse("v1.2.0-rc.2"),
+	}
+	stableLatest := &internal.Module{Path: path, Version: semver.MustParse("v1.1.0")}
+	tests := map[string]struct {
+		Policy   PrereleasePolicy
+		Current  string
+		Latest   *internal.Module
+		Expected string
+	}{
+		"always": {
+			Policy:   PrereleaseAlways,
+			Current:  "v1.0.0",
+			Latest:   stableLatest,
+			Expected: "v1.2.0-rc.2",
+		},
+		"current prerelease": {
+			Current:  "v1.1.0-rc.1",
+			Latest:   stableLatest,
+			Expected: "v1.2.0-rc.2",
+		},
+		"current stable": {
+			Current:  "v1.0.0",
+			Latest:   stableLatest,
+			Expected: "v1.1.0",
+		},
+		"stable, latest prerelease": {
+			Policy:   PrereleaseStable,
+			Current:  "v1.1.0-rc.1",
+			Latest:   &internal.Module{Path: path, Version: semver.MustParse("v1.2.0-rc.2")},
+			Expected: "v1.1.0",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			modulesRepo := mocks.NewMockModulesRepo(ctrl)
+			modulesRepo.EXPECT().
+				GetVersions(path).
+				MaxTimes(1).
+				Return(versions, nil)
+			modulesRepo.EXPECT().
+				GetInfo(path, gomock.Any()).
+				MaxTimes(1).
+				DoAndReturn(func(path string, version *semver.Version) (*internal.Module, error) {
+					return &internal.Module{Path: path, Version: version}, nil
+				})
+			cmd := Command{prereleases: test.Policy}
+
+			latest, err := cmd.applyPrereleasePolicy(modulesRepo, path, semver.MustParse(test.Current), test.Latest)
+
+			require.NoError(t, err)
+			assert.Equal(t, test.Expected, latest.Version.Original())
+		})
+	}
+}
+
+func TestCommand_FindLatestBefore_StablePrereleasePolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	path := "github.com/nieomylnieja/go-libyear"
+	modulesRepo := mocks.NewMockModulesRepo(ctrl)
+	modulesRepo.EXPECT().
+		GetVersions(path).
+		Times(1).
+		Return([]*semver.Version{
+			semver.MustParse("v1.0.0"),
+			semver.MustParse("v1.1.0-rc.1"),
+		}, nil)
+	modulesRepo.EXPECT().
+		GetInfo(path, semver.MustParse("v1.0.0")).
+		Times(1).
+		Return(&internal.Module{Version: semver.MustParse("v1.0.0"), Time: mustParseTime(t, "2023-01-01")}, nil)
+	cmd := Command{ageLimit: mustParseTime(t, "2024-01-01"), prereleases: PrereleaseStable}
+
+	latest, err := cmd.findLatestBefore(modulesRepo, path, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", latest.Version.String())
+}
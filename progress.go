@@ -0,0 +1,39 @@
+package libyear
+
+import "sync"
+
+// Progress describes how many of the analyzed modules were already processed.
+type Progress struct {
+	// Path of the module which was just processed.
+	Path string
+	// Done is the number of processed modules, including the one at Path.
+	Done int
+	// Total is the number of modules to process.
+	Total int
+}
+
+// ProgressFunc is called each time a module is processed.
+// Calls are never concurrent and Done grows with each call.
+type ProgressFunc func(progress Progress)
+
+// progressReporter serializes the calls of [ProgressFunc] made by concurrently processed modules.
+type progressReporter struct {
+	report ProgressFunc
+	total  int
+	mu     sync.Mutex
+	done   int
+}
+
+func newProgressReporter(report ProgressFunc, total int) *progressReporter {
+	return &progressReporter{report: report, total: total}
+}
+
+func (p *progressReporter) moduleDone(path string) {
+	if p.report == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	p.report(Progress{Path: path, Done: p.done, Total: p.total})
+}
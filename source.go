@@ -10,6 +10,8 @@ import (
 	"github.com/Masterminds/semver"
 
 	"github.com/pkg/errors"
+
+	"github.com/nieomylnieja/go-libyear/internal"
 )
 
 type Source interface {
@@ -17,9 +19,10 @@ type Source interface {
 }
 
 type PkgSource struct {
-	Pkg  string
-	repo ModulesRepo
-	vcs  *VCSRegistry
+	Pkg      string
+	repo     ModulesRepo
+	vcs      *VCSRegistry
+	verifier *internal.ModFileVerifier
 }
 
 func (p *PkgSource) Read() ([]byte, error) {
@@ -55,7 +58,16 @@ func (p *PkgSource) Read() ([]byte, error) {
 		}
 		version = latest.Version
 	}
-	return repo.GetModFile(path, version)
+	data, err := repo.GetModFile(path, version)
+	if err != nil {
+		return nil, err
+	}
+	if p.verifier != nil {
+		if err = p.verifier.Verify(path, version, data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
 }
 
 func (p *PkgSource) SetModulesRepo(repo ModulesRepo) {
@@ -66,11 +78,23 @@ func (p *PkgSource) SetVCSRegistry(registry *VCSRegistry) {
 	p.vcs = registry
 }
 
+func (p *PkgSource) SetModFileVerifier(verifier *internal.ModFileVerifier) {
+	p.verifier = verifier
+}
+
 type URLSource struct {
 	HTTP   http.Client
 	RawURL string
 }
 
+// SetHTTPClient sets the client used to fetch go.mod file, unless HTTP has its transport already set.
+// The client authenticates the request and retries it on failure.
+func (s *URLSource) SetHTTPClient(client *http.Client) {
+	if s.HTTP.Transport == nil {
+		s.HTTP = *client
+	}
+}
+
 func (s URLSource) Read() ([]byte, error) {
 	u, err := url.Parse(s.RawURL)
 	if err != nil {
@@ -0,0 +1,14 @@
+module github.com/test/test
+
+go 1.21
+
+require (
+	github.com/BurntSushi/toml v0.4.1
+	github.com/pkg/errors v0.8.0
+	golang.org/x/sync v0.5.0
+)
+
+replace (
+	github.com/pkg/errors => github.com/pkg/errors v0.9.0
+	golang.org/x/sync v0.5.0 => ../sync
+)
@@ -0,0 +1,10 @@
+module github.com/test/test
+
+go 1.24
+
+require (
+	github.com/BurntSushi/toml v0.4.1 // indirect
+	github.com/pkg/errors v0.8.0
+)
+
+tool github.com/BurntSushi/toml/cmd/tomlv
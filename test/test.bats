@@ -161,6 +161,126 @@ setup() {
 	assert_output_equals all_with_age_limit
 }
 
+@test "go_proxy: toolchain" {
+	run go-libyear --toolchain --go-releases "$INPUTS/go-releases.json" --releases "$TEST_GO_MOD"
+	assert_success
+	assert_output_equals toolchain
+}
+
+@test "go_proxy: toolchain, json output" {
+	run go-libyear --toolchain --go-releases "$INPUTS/go-releases.json" --json "$TEST_GO_MOD"
+	assert_success
+	assert_output_equals toolchain.json
+}
+
+@test "go_proxy: toolchain, csv output" {
+	run go-libyear --toolchain --go-releases "$INPUTS/go-releases.json" --csv "$TEST_GO_MOD"
+	assert_success
+	assert_output_equals toolchain.csv
+}
+
+@test "go_proxy: replaced modules" {
+	run go-libyear "$INPUTS/replace-go.mod"
+	assert_success
+	assert_output_equals replace
+}
+
+@test "go_proxy: replaced modules, json output" {
+	run go-libyear --json "$INPUTS/replace-go.mod"
+	assert_success
+	assert_output_equals replace.json
+}
+
+@test "go_proxy: replaced modules, csv output" {
+	run go-libyear --csv "$INPUTS/replace-go.mod"
+	assert_success
+	assert_output_equals replace.csv
+}
+
+@test "go_proxy: tools" {
+	run go-libyear "$INPUTS/tool-go.mod"
+	assert_success
+	assert_output_equals tools
+}
+
+@test "go_proxy: separate tools, json output" {
+	run go-libyear --separate-tools --json "$INPUTS/tool-go.mod"
+	assert_success
+	assert_output_equals tools_separate.json
+}
+
+@test "go_proxy: separate tools" {
+	run go-libyear --separate-tools "$INPUTS/tool-go.mod"
+	assert_success
+	assert_output_equals tools_separate
+}
+
+@test "go_proxy: separate tools, csv output" {
+	run go-libyear --separate-tools --csv "$INPUTS/tool-go.mod"
+	assert_success
+	assert_output_equals tools_separate.csv
+}
+
+@test "go_proxy: incompatible modules migrations" {
+	run go-libyear --migrations "$TEST_GO_MOD"
+	assert_success
+	assert_output_equals migrations
+}
+
+@test "go_proxy: incompatible modules migrations, json output" {
+	run go-libyear --migrations --json "$TEST_GO_MOD"
+	assert_success
+	assert_output_equals migrations.json
+}
+
+@test "go_proxy: explain" {
+	run --separate-stderr go-libyear --releases --versions --find-latest-major explain github.com/lestrrat-go/jwx "$TEST_GO_MOD"
+	assert_success
+	assert_output_equals explain
+}
+
+@test "go_proxy: vulnerabilities" {
+	run go-libyear --vuln-db "$INPUTS/vulndb" "$TEST_GO_MOD"
+	assert_success
+	assert_output_equals vulnerabilities
+}
+
+@test "go_proxy: vulnerabilities, json output" {
+	run go-libyear --vuln-db "$INPUTS/vulndb" --json "$TEST_GO_MOD"
+	assert_success
+	assert_output_equals vulnerabilities.json
+}
+
+@test "go_proxy: activity" {
+	run --separate-stderr go-libyear --activity --activity-releases 3 --inactive-after 8760h --age-limit 2024-01-15T00:00:00Z "$TEST_GO_MOD"
+	assert_success
+	assert_output_equals activity
+}
+
+@test "go_proxy: activity, json output" {
+	run --separate-stderr go-libyear --activity --age-limit 2024-01-15T00:00:00Z --json "$TEST_GO_MOD"
+	assert_success
+	assert_output_equals activity.json
+}
+
+@test "go_proxy: mean weighted by direct dependencies" {
+	run go-libyear --aggregate mean --weighting direct --indirect "$TEST_GO_MOD"
+	assert_success
+	assert_output_equals aggregate_mean_direct
+}
+
+@test "go_proxy: mean weighted by direct dependencies, csv output" {
+	run go-libyear --aggregate mean --weighting direct --indirect --csv "$TEST_GO_MOD"
+	assert_success
+	assert_output_equals aggregate_mean_direct.csv
+}
+
+@test "go_proxy: percentile weighted by config, json output" {
+	run go-libyear --aggregate p90 --weights "$INPUTS/weights.json" --json "$TEST_GO_MOD"
+	assert_success
+	assert_output_equals aggregate_p90_config.json
+}
+
 @test "go_proxy: cache with XDG_CACHE_HOME" {
 	export XDG_CACHE_HOME="$BATS_TEST_TMPDIR"
 	run go-libyear --cache "$TEST_GO_MOD"
@@ -0,0 +1,197 @@
+package libyear
+
+import (
+	goversion "go/version"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+
+	"github.com/nieomylnieja/go-libyear/internal"
+)
+
+// toolchainModulePath is the module which distributes Go toolchains through GOPROXY.
+// It was introduced with Go 1.21.
+const toolchainModulePath = "golang.org/toolchain"
+
+const (
+	// firstToolchainModuleVersion is the first Go version distributed through [toolchainModulePath].
+	firstToolchainModuleVersion = "go1.21rc1"
+	// firstPatchSuffixedLang is the first language version which releases are suffixed with '.0'.
+	firstPatchSuffixedLang = "go1.21"
+)
+
+type GoReleasesGetter interface {
+	GetGoReleases() ([]internal.GoRelease, error)
+}
+
+// ToolchainSummary contains the freshness metrics of Go toolchain,
+// declared with 'go' and 'toolchain' directives.
+type ToolchainSummary struct {
+	// Module holds the calculated metrics, the path is always 'go'.
+	Module *internal.Module
+	// Supported reports whether the version is within Go's two-release support window.
+	Supported bool
+}
+
+// runForToolchain analyzes the version declared with 'toolchain' directive or,
+// if it's not present, with 'go' directive, against the list of released Go versions.
+func (c Command) runForToolchain(goMod *internal.GoMod) (*ToolchainSummary, error) {
+	current := goMod.Toolchain
+	if current == "" || current == "default" {
+		if goMod.GoVersion == "" {
+			return nil, errors.New("go.mod file contains neither go nor toolchain directive")
+		}
+		current = "go" + goMod.GoVersion
+	}
+	current = normalizeGoVersion(current)
+	if !goversion.IsValid(current) {
+		return nil, errors.Errorf("invalid Go version: %s", current)
+	}
+
+	allReleases, err := c.goReleases.GetGoReleases()
+	if err != nil {
+		return nil, err
+	}
+	releases := make([]internal.GoRelease, 0, len(allReleases))
+	for _, release := range allReleases {
+		if release.Stable && goversion.IsValid(release.Version) {
+			releases = append(releases, release)
+		}
+	}
+	if len(releases) == 0 {
+		return nil, errors.New("no stable Go releases found")
+	}
+	slices.SortFunc(releases, func(a, b internal.GoRelease) int { return goversion.Compare(a.Version, b.Version) })
+
+	latestIndex := len(releases) - 1
+	latestTime, err := c.getGoReleaseTime(releases[latestIndex])
+	if err != nil {
+		return nil, err
+	}
+	// Walk back until we find the latest release published before the age limit.
+	for !c.ageLimit.IsZero() && latestTime.After(c.ageLimit) {
+		latestIndex--
+		if latestIndex < 0 {
+			return nil, errors.Wrapf(errNoMatchingVersions, "no Go release was published before %s",
+				c.ageLimit.Format(time.DateOnly))
+		}
+		if latestTime, err = c.getGoReleaseTime(releases[latestIndex]); err != nil {
+			return nil, err
+		}
+	}
+	releases = releases[:latestIndex+1]
+	latestRelease := releases[latestIndex]
+
+	currentRelease := internal.GoRelease{Version: current}
+	if i := slices.IndexFunc(releases, func(r internal.GoRelease) bool { return r.Version == current }); i != -1 {
+		currentRelease = releases[i]
+	}
+	currentTime, err := c.getGoReleaseTime(currentRelease)
+	if err != nil {
+		return nil, err
+	}
+
+	module, err := newToolchainModule(current, currentTime)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := newToolchainModule(latestRelease.Version, latestTime)
+	if err != nil {
+		return nil, err
+	}
+	module.Latest = latest
+	module.Libyear = calculateLibyear(currentTime, latestTime)
+	for _, release := range releases {
+		if goversion.Compare(release.Version, current) > 0 {
+			module.ReleasesDiff++
+		}
+	}
+	if c.optionIsSet(OptionShowVersions) {
+		module.VersionsDiff = calculateVersions(module, latest)
+	}
+	return &ToolchainSummary{
+		Module:    module,
+		Supported: isGoVersionSupported(current, releases),
+	}, nil
+}
+
+// getGoReleaseTime returns the release date of the Go version.
+// The date is either provided by the release source, hardcoded for versions predating
+// toolchain module or fetched from GOPROXY for golang.org/toolchain module.
+func (c Command) getGoReleaseTime(release internal.GoRelease) (time.Time, error) {
+	if !release.Time.IsZero() {
+		return release.Time, nil
+	}
+	if goversion.Compare(release.Version, firstToolchainModuleVersion) < 0 {
+		t, ok := internal.LegacyGoReleaseTime(goversion.Lang(release.Version))
+		if !ok {
+			return time.Time{}, errors.Errorf("unknown release date of %s", release.Version)
+		}
+		return t, nil
+	}
+	// Toolchain module versions have the following form: v0.0.1-go1.21.3.linux-amd64.
+	// Every platform is released at the same time, we're using linux-amd64 as it is always available.
+	version, err := semver.NewVersion("v0.0.1-" + release.Version + ".linux-amd64")
+	if err != nil {
+		return time.Time{}, err
+	}
+	info, err := c.repo.GetInfo(toolchainModulePath, version)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to fetch %s release date", release.Version)
+	}
+	return info.Time, nil
+}
+
+// isGoVersionSupported reports whether the version's major release is one of
+// the two most recent major releases, which are supported by the Go team.
+// Ref: https://go.dev/doc/devel/release#policy.
+func isGoVersionSupported(version string, releases []internal.GoRelease) bool {
+	lang := goversion.Lang(version)
+	supported := make([]string, 0, 2)
+	for i := len(releases) - 1; i >= 0 && len(supported) < 2; i-- {
+		releaseLang := goversion.Lang(releases[i].Version)
+		if !slices.Contains(supported, releaseLang) {
+			supported = append(supported, releaseLang)
+		}
+	}
+	return slices.Contains(supported, lang)
+}
+
+// normalizeGoVersion converts the language version declared with 'go' directive,
+// e.g. 'go1.21', into the first release of this version, e.g. 'go1.21.0'.
+// Prior to Go 1.21, first releases were not suffixed with '.0'.
+func normalizeGoVersion(version string) string {
+	if goversion.Lang(version) == version && goversion.Compare(version, firstPatchSuffixedLang) >= 0 {
+		return version + ".0"
+	}
+	return version
+}
+
+// newToolchainModule creates a module representing Go toolchain.
+// Go versions are converted to semver, e.g. 'go1.21rc1' becomes 'v1.21.0-rc1'.
+func newToolchainModule(goVersion string, t time.Time) (*internal.Module, error) {
+	v := strings.TrimPrefix(goVersion, "go")
+	number, prerelease := v, ""
+	if i := strings.IndexFunc(v, func(r rune) bool { return r != '.' && !unicode.IsDigit(r) }); i != -1 {
+		number, prerelease = v[:i], v[i:]
+	}
+	for strings.Count(number, ".") < 2 {
+		number += ".0"
+	}
+	if prerelease != "" {
+		number += "-" + prerelease
+	}
+	version, err := semver.NewVersion("v" + number)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to convert Go version %s to semver", goVersion)
+	}
+	return &internal.Module{
+		Path:    "go",
+		Version: version,
+		Time:    t,
+	}, nil
+}
@@ -0,0 +1,91 @@
+package libyear
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/nieomylnieja/go-libyear/internal"
+	"github.com/nieomylnieja/go-libyear/internal/mocks"
+)
+
+type goReleasesGetterFunc func() ([]internal.GoRelease, error)
+
+func (f goReleasesGetterFunc) GetGoReleases() ([]internal.GoRelease, error) { return f() }
+
+func TestCommand_RunForToolchain(t *testing.T) {
+	releases := []internal.GoRelease{
+		{Version: "go1.22.1", Stable: true},
+		{Version: "go1.23rc1", Stable: false},
+		{Version: "go1.21.0", Stable: true},
+		{Version: "go1.22.0", Stable: true},
+		{Version: "go1.20", Stable: true},
+		{Version: "go1.20.1", Stable: true},
+	}
+	tests := map[string]struct {
+		GoMod            *internal.GoMod
+		GetInfoResponses map[string]string
+		ExpectedVersion  string
+		ExpectedReleases int
+		ExpectedLibyear  float64
+		Supported        bool
+	}{
+		"legacy go directive": {
+			GoMod: &internal.GoMod{GoVersion: "1.20"},
+			GetInfoResponses: map[string]string{
+				"v0.0.1-go1.22.1.linux-amd64": "2024-03-05",
+			},
+			ExpectedVersion:  "v1.20.0",
+			ExpectedReleases: 4,
+			ExpectedLibyear:  1.09,
+		},
+		"go directive": {
+			GoMod: &internal.GoMod{GoVersion: "1.22"},
+			GetInfoResponses: map[string]string{
+				"v0.0.1-go1.22.0.linux-amd64": "2024-02-06",
+				"v0.0.1-go1.22.1.linux-amd64": "2024-03-05",
+			},
+			ExpectedVersion:  "v1.22.0",
+			ExpectedReleases: 1,
+			ExpectedLibyear:  0.08,
+			Supported:        true,
+		},
+		"toolchain directive takes precedence": {
+			GoMod: &internal.GoMod{GoVersion: "1.21", Toolchain: "go1.22.1"},
+			GetInfoResponses: map[string]string{
+				"v0.0.1-go1.22.1.linux-amd64": "2024-03-05",
+			},
+			ExpectedVersion:  "v1.22.1",
+			ExpectedReleases: 0,
+			Supported:        true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			modulesRepo := mocks.NewMockModulesRepo(ctrl)
+			for version, date := range test.GetInfoResponses {
+				modulesRepo.EXPECT().
+					GetInfo("golang.org/toolchain", semver.MustParse(version)).
+					MinTimes(1).
+					Return(&internal.Module{Time: mustParseTime(t, date)}, nil)
+			}
+			cmd := Command{
+				repo:       modulesRepo,
+				goReleases: goReleasesGetterFunc(func() ([]internal.GoRelease, error) { return releases, nil }),
+			}
+
+			summary, err := cmd.runForToolchain(test.GoMod)
+
+			require.NoError(t, err)
+			assert.Equal(t, test.ExpectedVersion, "v"+summary.Module.Version.String())
+			assert.Equal(t, "1.22.1", summary.Module.Latest.Version.String())
+			assert.Equal(t, test.ExpectedReleases, summary.Module.ReleasesDiff)
+			assert.InDelta(t, test.ExpectedLibyear, summary.Module.Libyear, 0.01)
+			assert.Equal(t, test.Supported, summary.Supported)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package libyear
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -30,6 +31,27 @@ func NewVCSRegistry(cacheDir string) *VCSRegistry {
 	}
 }
 
+// NewLightweightVCSRegistry works like [NewVCSRegistry], but the registered handlers
+// avoid downloading whole repositories, e.g. git handler lists tags with 'git ls-remote'
+// and only creates bare, blobless clones when commit dates or go.mod files are needed.
+func NewLightweightVCSRegistry(cacheDir string) *VCSRegistry {
+	return &VCSRegistry{
+		vcsHandlers: []VCSHandler{
+			internal.NewLightweightGitVCS(cacheDir, internal.GitCmd{}),
+		},
+		goprivate: os.Getenv("GOPRIVATE"),
+	}
+}
+
+// DefaultVCSCacheDir returns the default directory for VCS modules' cache.
+func DefaultVCSCacheDir() (string, error) {
+	cacheBase, err := internal.GetDefaultCacheBasePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheBase, "vcs"), nil
+}
+
 // VCSRegistry implements [command.ModulesRepo] and delegates handling of an
 // invoked method to the registered VCS handler which supports the given path.
 type VCSRegistry struct {
@@ -37,6 +59,17 @@ type VCSRegistry struct {
 	goprivate   string
 }
 
+// SetConcurrencyLimit limits the number of concurrently running VCS subprocesses, e.g. git commands,
+// of each registered handler which supports it. Zero means no limit.
+// It must be called before the registry is used.
+func (v *VCSRegistry) SetConcurrencyLimit(limit int) {
+	for _, handler := range v.vcsHandlers {
+		if h, ok := handler.(interface{ SetConcurrencyLimit(limit int) }); ok {
+			h.SetConcurrencyLimit(limit)
+		}
+	}
+}
+
 func (v *VCSRegistry) IsPrivate(path string) bool {
 	return module.MatchPrefixPatterns(v.goprivate, path)
 }
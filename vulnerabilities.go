@@ -0,0 +1,88 @@
+package libyear
+
+import (
+	"sort"
+
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+
+	"github.com/nieomylnieja/go-libyear/internal"
+)
+
+type VulnerabilitiesGetter interface {
+	GetVulnerabilities(path string, version *semver.Version) ([]internal.Vulnerability, error)
+}
+
+// checkVulnerabilities lists known vulnerabilities affecting the current module version.
+// If all of them were fixed, the libyear between the current version and the first version
+// fixing every vulnerability is calculated.
+func (c Command) checkVulnerabilities(repo ModulesRepo, module *internal.Module) error {
+	vulns, err := c.vulnDB.GetVulnerabilities(module.Path, module.Version)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check vulnerabilities of %s@v%s", module.Path, module.Version)
+	}
+	module.Vulnerabilities = vulns
+	var fixed *semver.Version
+	for _, vuln := range vulns {
+		if vuln.Fixed == nil {
+			// There's no version which fixes every vulnerability.
+			return nil
+		}
+		if fixed == nil || vuln.Fixed.GreaterThan(fixed) {
+			fixed = vuln.Fixed
+		}
+	}
+	if fixed == nil {
+		return nil
+	}
+	fixed, err = c.findFixedVersion(repo, module, fixed)
+	if err != nil || fixed == nil {
+		return err
+	}
+	fixedModule, err := repo.GetInfo(module.Path, fixed)
+	if err != nil {
+		if internal.IsNotFound(err) {
+			// Fixed version is not available, e.g. it was never published or was removed.
+			return nil
+		}
+		return err
+	}
+	module.Fixed = fixedModule
+	module.FixedLibyear = calculateLibyear(module.Time, fixedModule.Time)
+	return nil
+}
+
+// findFixedVersion finds the first listed version, starting from the greatest fixed version,
+// which is not affected by any known vulnerability.
+// Versions in between might fall into another vulnerability's affected range.
+// If there's no such version, nil is returned.
+func (c Command) findFixedVersion(
+	repo ModulesRepo,
+	module *internal.Module,
+	fixed *semver.Version,
+) (*semver.Version, error) {
+	versions, err := repo.GetVersions(module.Path)
+	if err != nil {
+		if internal.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	isPrerelease := module.Version.Prerelease() != ""
+	sort.Sort(semver.Collection(versions))
+	for _, version := range versions {
+		if version.LessThan(fixed) ||
+			c.excludes.IsExcluded(module.Path, version) ||
+			(!isPrerelease && version.Prerelease() != "" && !version.Equal(fixed)) {
+			continue
+		}
+		vulns, err := c.vulnDB.GetVulnerabilities(module.Path, version)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to check vulnerabilities of %s@v%s", module.Path, version)
+		}
+		if len(vulns) == 0 {
+			return version, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,167 @@
+package libyear
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/nieomylnieja/go-libyear/internal"
+	"github.com/nieomylnieja/go-libyear/internal/mocks"
+)
+
+type vulnerabilitiesGetterFunc func(path string, version *semver.Version) ([]internal.Vulnerability, error)
+
+func (f vulnerabilitiesGetterFunc) GetVulnerabilities(
+	path string,
+	version *semver.Version,
+) ([]internal.Vulnerability, error) {
+	return f(path, version)
+}
+
+func TestCommand_CheckVulnerabilities(t *testing.T) {
+	path := "github.com/nieomylnieja/go-libyear"
+	tests := map[string]struct {
+		Vulns []internal.Vulnerability
+		// Affected lists vulnerabilities affecting versions other than the current one.
+		Affected        map[string][]internal.Vulnerability
+		Versions        []string
+		ExpectedFixed   string
+		ExpectedLibyear float64
+	}{
+		"no vulnerabilities": {},
+		"greatest fixed version fixes all": {
+			Vulns: []internal.Vulnerability{
+				{ID: "GO-0000-0001", Fixed: semver.MustParse("v1.2.0")},
+				{ID: "GO-0000-0002", Fixed: semver.MustParse("v1.1.0")},
+			},
+			Versions:        []string{"v1.2.0", "v1.0.0", "v1.1.0"},
+			ExpectedFixed:   "v1.2.0",
+			ExpectedLibyear: 1,
+		},
+		"greatest fixed version affected by another vulnerability": {
+			Vulns: []internal.Vulnerability{
+				{ID: "GO-0000-0001", Fixed: semver.MustParse("v1.2.0")},
+			},
+			Affected: map[string][]internal.Vulnerability{
+				"v1.2.0":      {{ID: "GO-0000-0003", Fixed: semver.MustParse("v1.3.0")}},
+				"v1.2.1":      {{ID: "GO-0000-0003", Fixed: semver.MustParse("v1.3.0")}},
+				"v1.3.0-rc.1": nil,
+			},
+			Versions:        []string{"v1.0.0", "v1.2.0", "v1.2.1", "v1.3.0-rc.1", "v1.3.0", "v1.4.0"},
+			ExpectedFixed:   "v1.3.0",
+			ExpectedLibyear: 1,
+		},
+		"every newer version is affected": {
+			Vulns: []internal.Vulnerability{
+				{ID: "GO-0000-0001", Fixed: semver.MustParse("v1.2.0")},
+			},
+			Affected: map[string][]internal.Vulnerability{
+				"v1.2.0": {{ID: "GO-0000-0003"}},
+			},
+			Versions: []string{"v1.0.0", "v1.2.0"},
+		},
+		"fixed version is not listed": {
+			Vulns: []internal.Vulnerability{
+				{ID: "GO-0000-0001", Fixed: semver.MustParse("v1.2.0")},
+			},
+			Versions: []string{"v1.0.0", "v1.1.0"},
+		},
+		"unfixed vulnerability": {
+			Vulns: []internal.Vulnerability{
+				{ID: "GO-0000-0001", Fixed: semver.MustParse("v1.2.0")},
+				{ID: "GO-0000-0002"},
+			},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			modulesRepo := mocks.NewMockModulesRepo(ctrl)
+			if test.Versions != nil {
+				versions := make([]*semver.Version, 0, len(test.Versions))
+				for _, v := range test.Versions {
+					versions = append(versions, semver.MustParse(v))
+				}
+				modulesRepo.EXPECT().
+					GetVersions(path).
+					Times(1).
+					Return(versions, nil)
+			}
+			if test.ExpectedFixed != "" {
+				modulesRepo.EXPECT().
+					GetInfo(path, semver.MustParse(test.ExpectedFixed)).
+					Times(1).
+					Return(&internal.Module{
+						Path:    path,
+						Version: semver.MustParse(test.ExpectedFixed),
+						Time:    mustParseTime(t, "2024-01-01"),
+					}, nil)
+			}
+			module := &internal.Module{
+				Path:    path,
+				Version: semver.MustParse("v1.0.0"),
+				Time:    mustParseTime(t, "2023-01-01"),
+			}
+			cmd := Command{
+				vulnDB: vulnerabilitiesGetterFunc(func(_ string, version *semver.Version) ([]internal.Vulnerability, error) {
+					if version.Equal(module.Version) {
+						return test.Vulns, nil
+					}
+					return test.Affected["v"+version.String()], nil
+				}),
+			}
+
+			err := cmd.checkVulnerabilities(modulesRepo, module)
+
+			require.NoError(t, err)
+			assert.Equal(t, test.Vulns, module.Vulnerabilities)
+			if test.ExpectedFixed == "" {
+				assert.Nil(t, module.Fixed)
+				assert.Zero(t, module.FixedLibyear)
+			} else {
+				assert.Equal(t, semver.MustParse(test.ExpectedFixed), module.Fixed.Version)
+				assert.InEpsilon(t, test.ExpectedLibyear, module.FixedLibyear, 0.01)
+			}
+		})
+	}
+}
+
+func TestCommand_CheckVulnerabilities_FixedVersionNotFound(t *testing.T) {
+	path := "github.com/nieomylnieja/go-libyear"
+	ctrl := gomock.NewController(t)
+	modulesRepo := mocks.NewMockModulesRepo(ctrl)
+	modulesRepo.EXPECT().
+		GetVersions(path).
+		Times(1).
+		Return([]*semver.Version{semver.MustParse("v1.0.0"), semver.MustParse("v1.2.0")}, nil)
+	modulesRepo.EXPECT().
+		GetInfo(path, semver.MustParse("v1.2.0")).
+		Times(1).
+		Return(nil, errors.WithStack(&internal.ResponseError{StatusCode: http.StatusGone}))
+	module := &internal.Module{
+		Path:    path,
+		Version: semver.MustParse("v1.0.0"),
+		Time:    mustParseTime(t, "2023-01-01"),
+	}
+	vulns := []internal.Vulnerability{{ID: "GO-0000-0001", Fixed: semver.MustParse("v1.2.0")}}
+	cmd := Command{
+		vulnDB: vulnerabilitiesGetterFunc(func(_ string, version *semver.Version) ([]internal.Vulnerability, error) {
+			if version.Equal(module.Version) {
+				return vulns, nil
+			}
+			return nil, nil
+		}),
+	}
+
+	err := cmd.checkVulnerabilities(modulesRepo, module)
+
+	require.NoError(t, err)
+	assert.Equal(t, vulns, module.Vulnerabilities)
+	assert.Nil(t, module.Fixed)
+	assert.Zero(t, module.FixedLibyear)
+}